@@ -0,0 +1,9 @@
+// Package easykatka хранит ресурсы, которые вшиваются в бинарник.
+package easykatka
+
+import "embed"
+
+// Images содержит портреты героев (images/heroes/<имя>.png) и логотип бота.
+//
+//go:embed images/heroes/*.png images/botlogo.png
+var Images embed.FS
@@ -3,12 +3,14 @@ package main
 import (
 	"fmt"
 	"os"
+	// База часовых поясов встроена в бинарник: в образе на Alpine её нет.
+	_ "time/tzdata"
 
 	"easyKatka/internal/app"
 )
 
 func main() {
-	if err := app.Run(); err != nil {
+	if err := app.Run(os.Args[1:]); err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
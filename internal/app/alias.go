@@ -0,0 +1,208 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// aliasStore — постоянные имена игроков. base приходят из файла аккаунтов
+// или конфига, custom задаются командой /alias и хранятся в data/aliases.json;
+// custom важнее base.
+type aliasStore struct {
+	mu     sync.RWMutex
+	path   string
+	base   map[int64]string
+	custom map[int64]string
+}
+
+func newAliasStore() *aliasStore {
+	return &aliasStore{base: make(map[int64]string), custom: make(map[int64]string)}
+}
+
+// Load читает aliases.json; отсутствие файла не ошибка.
+func (s *aliasStore) Load(path string) error {
+	custom := make(map[int64]string)
+	raw, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("read aliases: %w", err)
+	default:
+		var stored map[string]string
+		if err := json.Unmarshal(raw, &stored); err != nil {
+			return fmt.Errorf("parse aliases: %w", err)
+		}
+		for key, alias := range stored {
+			id, err := strconv.ParseInt(key, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse aliases: некорректный account_id %q", key)
+			}
+			custom[id] = alias
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path = path
+	s.custom = custom
+	return nil
+}
+
+func (s *aliasStore) SetBase(accounts []accountConfig) {
+	base := make(map[int64]string, len(accounts))
+	for _, account := range accounts {
+		if account.Alias != "" {
+			base[account.ID] = account.Alias
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.base = base
+}
+
+func (s *aliasStore) Get(accountID int64) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if alias, ok := s.custom[accountID]; ok {
+		return alias
+	}
+	return s.base[accountID]
+}
+
+// Set задаёт alias из /alias; пустой alias возвращает имя из файла аккаунтов.
+func (s *aliasStore) Set(accountID int64, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, had := s.custom[accountID]
+	if alias == "" {
+		delete(s.custom, accountID)
+	} else {
+		s.custom[accountID] = alias
+	}
+	if err := s.saveLocked(); err != nil {
+		if had {
+			s.custom[accountID] = previous
+		} else {
+			delete(s.custom, accountID)
+		}
+		return err
+	}
+	return nil
+}
+
+// List возвращает все действующие alias, отсортированные по account_id.
+func (s *aliasStore) List() []accountConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make(map[int64]struct{}, len(s.base)+len(s.custom))
+	for id := range s.base {
+		ids[id] = struct{}{}
+	}
+	for id := range s.custom {
+		ids[id] = struct{}{}
+	}
+	result := make([]accountConfig, 0, len(ids))
+	for id := range ids {
+		alias, ok := s.custom[id]
+		if !ok {
+			alias = s.base[id]
+		}
+		result = append(result, accountConfig{ID: id, Alias: alias})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// saveLocked пишет custom в файл; без Load alias живут только в памяти.
+func (s *aliasStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	stored := make(map[string]string, len(s.custom))
+	for id, alias := range s.custom {
+		stored[strconv.FormatInt(id, 10)] = alias
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create aliases dir: %w", err)
+	}
+	raw, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal aliases: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write aliases: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("write aliases: %w", err)
+	}
+	return nil
+}
+
+// displayName — имя игрока в отчётах: alias, а рядом имя в Steam, если оно
+// отличается. Без alias — имя в Steam, как раньше.
+func displayName(accountID int64, personaName string) string {
+	persona := fallbackName(personaName)
+	alias := playerAliases.Get(accountID)
+	switch {
+	case alias == "":
+		return persona
+	case strings.TrimSpace(personaName) == "" || strings.EqualFold(alias, persona):
+		return alias
+	}
+	return fmt.Sprintf("%s (%s)", alias, persona)
+}
+
+// parseAliasArgs: без аргументов — список, "<игрок> <имя>" — задать,
+// "<игрок> -" — сбросить.
+func parseAliasArgs(raw []string) (commandArgs, error) {
+	if len(raw) == 0 {
+		return commandArgs{Raw: raw}, nil
+	}
+	if len(raw) < 2 {
+		return commandArgs{}, newLocalizedError("usage.alias")
+	}
+	alias := strings.Join(raw[1:], " ")
+	if alias == "-" {
+		alias = ""
+	}
+	if runeLen(alias) > 32 {
+		return commandArgs{}, newLocalizedError("err.alias_long")
+	}
+	return commandArgs{Raw: raw, Targets: []string{raw[0]}, Text: alias}, nil
+}
+
+func handleAliasCommand(bot *telegramBot, req commandRequest) error {
+	if len(req.Args.Targets) == 0 {
+		return sendTelegramMessage(bot.apiBase, req.ChatID, formatAliasList(req.Locale, loadTrackedPlayers(bot.accountStore.Get())), "", nil)
+	}
+	player, err := resolveTrackedPlayer(loadTrackedPlayers(bot.accountStore.Get()), req.Args.Targets[0])
+	if err != nil {
+		return err
+	}
+	if err := playerAliases.Set(player.AccountID, req.Args.Text); err != nil {
+		return err
+	}
+	text := req.Locale.T("alias.set", player.AccountID, displayName(player.AccountID, player.Persona))
+	return sendTelegramMessage(bot.apiBase, req.ChatID, text, "", nil)
+}
+
+func formatAliasList(loc locale, players []trackedPlayer) string {
+	var lines []string
+	for _, player := range players {
+		if player.Alias == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%d — %s", player.AccountID, player.Name))
+	}
+	if len(lines) == 0 {
+		return loc.T("alias.none")
+	}
+	return strings.Join(lines, "\n")
+}
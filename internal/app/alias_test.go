@@ -0,0 +1,94 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// withAliases подменяет глобальный playerAliases на время теста.
+func withAliases(t *testing.T) *aliasStore {
+	t.Helper()
+	previous := playerAliases
+	playerAliases = newAliasStore()
+	t.Cleanup(func() { playerAliases = previous })
+	return playerAliases
+}
+
+func TestDisplayName(t *testing.T) {
+	aliases := withAliases(t)
+	aliases.SetBase([]accountConfig{{ID: 1, Alias: "Вася"}, {ID: 2, Alias: "Petya"}})
+	cases := []struct {
+		id      int64
+		persona string
+		want    string
+	}{
+		{1, "xX_killer_Xx", "Вася (xX_killer_Xx)"},
+		{1, "", "Вася"},
+		{2, "petya", "Petya"},
+		{3, "Steam", "Steam"},
+		{3, "", "неизвестный"},
+	}
+	for _, tc := range cases {
+		if got := displayName(tc.id, tc.persona); got != tc.want {
+			t.Fatalf("displayName(%d, %q)=%q, want %q", tc.id, tc.persona, got, tc.want)
+		}
+	}
+}
+
+func TestAliasStorePersistsCustomAliases(t *testing.T) {
+	aliases := withAliases(t)
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	if err := aliases.Load(path); err != nil {
+		t.Fatalf("load missing file: %v", err)
+	}
+	aliases.SetBase([]accountConfig{{ID: 1, Alias: "Из файла"}})
+	if err := aliases.Set(1, "Из команды"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	reloaded := newAliasStore()
+	if err := reloaded.Load(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	reloaded.SetBase([]accountConfig{{ID: 1, Alias: "Из файла"}})
+	if got := reloaded.Get(1); got != "Из команды" {
+		t.Fatalf("alias=%q", got)
+	}
+	// Сброс возвращает alias из файла аккаунтов.
+	if err := reloaded.Set(1, ""); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if got := reloaded.Get(1); got != "Из файла" {
+		t.Fatalf("alias after reset=%q", got)
+	}
+}
+
+func TestParseAliasArgs(t *testing.T) {
+	args, err := parseAliasArgs([]string{"123", "Вася", "Пупкин"})
+	if err != nil || args.Targets[0] != "123" || args.Text != "Вася Пупкин" {
+		t.Fatalf("args=%+v err=%v", args, err)
+	}
+	args, err = parseAliasArgs([]string{"123", "-"})
+	if err != nil || args.Text != "" {
+		t.Fatalf("reset args=%+v err=%v", args, err)
+	}
+	if args, err := parseAliasArgs(nil); err != nil || len(args.Targets) != 0 {
+		t.Fatalf("list args=%+v err=%v", args, err)
+	}
+	if _, err := parseAliasArgs([]string{"123"}); err == nil {
+		t.Fatal("expected error without name")
+	}
+}
+
+func TestMatchTrackedPlayersByAlias(t *testing.T) {
+	players := []trackedPlayer{
+		{AccountID: 1, Name: "Вася (xX_killer_Xx)", Alias: "Вася", Persona: "xX_killer_Xx"},
+		{AccountID: 2, Name: "Petya", Persona: "Petya"},
+	}
+	for _, query := range []string{"вася", "killer"} {
+		found := matchTrackedPlayers(players, query)
+		if len(found) == 0 || found[0].AccountID != 1 {
+			t.Fatalf("query %q: found=%+v", query, found)
+		}
+	}
+}
@@ -2,91 +2,156 @@ package app
 
 import (
 	"fmt"
-	"os"
-	"strconv"
-	"strings"
 	"sync"
 )
 
+// accountIDStore хранит отслеживаемые аккаунты вместе с их настройками
+// (alias, notify) и умеет перечитывать их из источника — файла account_id
+// или секции [[accounts]] конфига. files — файлы, за изменением которых
+// следит watchAccountStore.
 type accountIDStore struct {
-	mu  sync.RWMutex
-	ids []int64
+	mu          sync.RWMutex
+	ids         []int64
+	accounts    map[int64]accountConfig
+	source      string
+	files       []string
+	fingerprint string
+	load        func() ([]accountConfig, error)
+	reloadMu    sync.Mutex
 }
 
 func newAccountIDStore(ids []int64) *accountIDStore {
-	store := &accountIDStore{}
+	store := &accountIDStore{source: defaultAccountsPath}
 	store.Set(ids)
 	return store
 }
 
-func (s *accountIDStore) Get() []int64 {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return append([]int64(nil), s.ids...)
+// newAccountStore загружает аккаунты через load и запоминает его для Reload.
+func newAccountStore(source string, files []string, load func() ([]accountConfig, error)) (*accountIDStore, error) {
+	fingerprint := fileFingerprint(files)
+	accounts, err := load()
+	if err != nil {
+		return nil, err
+	}
+	store := &accountIDStore{source: source, files: files, fingerprint: fingerprint, load: load}
+	store.SetAccounts(accounts)
+	return store, nil
 }
 
-func (s *accountIDStore) Set(ids []int64) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.ids = append([]int64(nil), ids...)
+func loadAccountIDStore(path string) (*accountIDStore, error) {
+	return newAccountStore(path, []string{path}, func() ([]accountConfig, error) {
+		return loadAccountFile(path)
+	})
 }
 
-func Run() error {
-	accountIDs, err := loadAccountIDs("account_id")
+// loadConfigAccountStore берёт аккаунты из конфига; Reload перечитывает
+// конфиг целиком, чтобы подхватить правки [[accounts]] и paths.accounts.
+func loadConfigAccountStore(path string, cfg config) (*accountIDStore, error) {
+	store, err := newAccountStore(cfg.AccountsSource(), []string{path, cfg.Paths.Accounts}, cfg.LoadAccounts)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	store.load = func() ([]accountConfig, error) {
+		fresh, err := loadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		return fresh.LoadAccounts()
 	}
-	accountStore := newAccountIDStore(accountIDs)
+	return store, nil
+}
 
-	heroes, err := fetchHeroes()
-	if err != nil {
-		return err
+func accountsFromIDs(ids []int64) []accountConfig {
+	accounts := make([]accountConfig, 0, len(ids))
+	for _, id := range ids {
+		accounts = append(accounts, accountConfig{ID: id, Notify: true})
 	}
+	return accounts
+}
 
-	telegramToken := strings.TrimSpace(os.Getenv(telegramTokenEnv))
-	if telegramToken != "" {
-		if notify := telegramNotifier(telegramToken); notify != nil {
-			go monitorMatches(accountStore, heroes, notify)
-		}
-		return runTelegramBot(telegramToken, accountStore, heroes)
+// Source возвращает файл, из которого загружен список аккаунтов.
+func (s *accountIDStore) Source() string {
+	return s.source
+}
+
+// Accounts возвращает аккаунты с настройками в порядке источника.
+func (s *accountIDStore) Accounts() []accountConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	accounts := make([]accountConfig, 0, len(s.ids))
+	for _, id := range s.ids {
+		accounts = append(accounts, s.accounts[id])
 	}
+	return accounts
+}
 
-	report, err := buildReport(accountStore.Get(), heroes)
-	if err != nil {
-		return err
+func (s *accountIDStore) Get() []int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]int64(nil), s.ids...)
+}
+
+// Account возвращает настройки аккаунта; у неизвестных notify включён.
+func (s *accountIDStore) Account(id int64) accountConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if account, ok := s.accounts[id]; ok {
+		return account
 	}
-	fmt.Print(report)
+	return accountConfig{ID: id, Notify: true}
+}
 
-	monitorMatches(accountStore, heroes, nil)
-	return nil
+func (s *accountIDStore) Set(ids []int64) {
+	s.SetAccounts(accountsFromIDs(ids))
 }
 
-func loadAccountIDs(path string) ([]int64, error) {
-	raw, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read account_id: %w", err)
+func (s *accountIDStore) SetAccounts(accounts []accountConfig) {
+	playerAliases.SetBase(accounts)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = make([]int64, 0, len(accounts))
+	s.accounts = make(map[int64]accountConfig, len(accounts))
+	for _, account := range accounts {
+		s.ids = append(s.ids, account.ID)
+		s.accounts[account.ID] = account
 	}
-	lines := strings.Split(string(raw), "\n")
-	var ids []int64
-	for _, line := range lines {
-		value := strings.TrimSpace(line)
-		if value == "" {
-			continue
-		}
-		id, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("parse account_id: %w", err)
-		}
-		if id > maxUint32 {
-			id = id - steamID64Offset
-		}
-		if id <= 0 {
-			return nil, fmt.Errorf("invalid account_id after conversion: %d", id)
-		}
-		ids = append(ids, id)
+}
+
+// Reload перечитывает источник и возвращает изменения состава.
+func (s *accountIDStore) Reload() (rosterDiff, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	return s.reload(fileFingerprint(s.files))
+}
+
+// ReloadIfChanged перечитывает источник, только если файлы изменились.
+// Отпечаток запоминается и при ошибке, чтобы не повторять её до новой правки.
+func (s *accountIDStore) ReloadIfChanged() (rosterDiff, bool, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	fingerprint := fileFingerprint(s.files)
+	s.mu.RLock()
+	unchanged := fingerprint == s.fingerprint
+	s.mu.RUnlock()
+	if unchanged {
+		return rosterDiff{}, false, nil
+	}
+	diff, err := s.reload(fingerprint)
+	return diff, true, err
+}
+
+func (s *accountIDStore) reload(fingerprint string) (rosterDiff, error) {
+	if s.load == nil {
+		return rosterDiff{}, fmt.Errorf("список аккаунтов задан без источника")
 	}
-	if len(ids) == 0 {
-		return nil, fmt.Errorf("account_id is empty")
+	s.mu.Lock()
+	s.fingerprint = fingerprint
+	s.mu.Unlock()
+	accounts, err := s.load()
+	if err != nil {
+		return rosterDiff{}, err
 	}
-	return ids, nil
+	diff := diffRoster(s.Accounts(), accounts)
+	s.SetAccounts(accounts)
+	return diff, nil
 }
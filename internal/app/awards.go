@@ -0,0 +1,176 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type award struct {
+	Title     string
+	AccountID int64
+	HeroID    int
+	MatchID   int64
+	Value     string
+}
+
+type awardCandidate struct {
+	player  matchDetailsPlayer
+	details matchDetails
+	score   int
+}
+
+// awardRule — номинация; key — суффикс ключа "award." в каталоге сообщений.
+type awardRule struct {
+	key   string
+	score func(details matchDetails, player matchDetailsPlayer) (int, bool)
+	value func(loc locale, score int) string
+}
+
+var awardRules = []awardRule{
+	{
+		key:   "damage",
+		score: func(_ matchDetails, p matchDetailsPlayer) (int, bool) { return p.HeroDamage, p.HeroDamage > 0 },
+		value: func(_ locale, score int) string { return formatThousands(score) },
+	},
+	{
+		key:   "deaths",
+		score: func(_ matchDetails, p matchDetailsPlayer) (int, bool) { return p.Deaths, p.Deaths > 0 },
+		value: func(loc locale, score int) string { return loc.N(score, "deaths") },
+	},
+	{
+		key:   "gpm",
+		score: func(_ matchDetails, p matchDetailsPlayer) (int, bool) { return p.GPM, p.GPM > 0 },
+		value: func(_ locale, score int) string { return fmt.Sprintf("%d GPM", score) },
+	},
+	{
+		key:   "healing",
+		score: func(_ matchDetails, p matchDetailsPlayer) (int, bool) { return p.HeroHealing, p.HeroHealing > 0 },
+		value: func(_ locale, score int) string { return formatThousands(score) },
+	},
+	{
+		key:   "duration",
+		score: func(d matchDetails, _ matchDetailsPlayer) (int, bool) { return d.Duration, d.Duration > 0 },
+		value: func(_ locale, score int) string { return formatDuration(score) },
+	},
+	{
+		key:   "comeback",
+		score: comebackScore,
+		value: func(loc locale, score int) string { return loc.T("award.comeback.value", formatThousands(score)) },
+	},
+}
+
+// comebackScore — максимальное отставание по золоту, которое отыграла команда
+// игрока до победы. Нужен radiant_gold_adv, он есть только у разобранных матчей.
+func comebackScore(details matchDetails, player matchDetailsPlayer) (int, bool) {
+	if len(details.RadiantGoldAdv) == 0 || !isWin(details.RadiantWin, player.PlayerSlot) {
+		return 0, false
+	}
+	deficit := 0
+	for _, adv := range details.RadiantGoldAdv {
+		if player.PlayerSlot >= 128 {
+			adv = -adv
+		}
+		if -adv > deficit {
+			deficit = -adv
+		}
+	}
+	return deficit, deficit > 0
+}
+
+// computeAwards выбирает лучших отслеживаемых игроков по каждой номинации.
+// При равенстве побеждает более ранний матч.
+func computeAwards(loc locale, matches []matchDetails, tracked map[int64]struct{}) []award {
+	sorted := append([]matchDetails(nil), matches...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MatchID < sorted[j].MatchID })
+
+	awards := make([]award, 0, len(awardRules))
+	for _, rule := range awardRules {
+		var best *awardCandidate
+		for _, details := range sorted {
+			for _, player := range details.Players {
+				if _, ok := tracked[player.AccountID]; !ok {
+					continue
+				}
+				score, ok := rule.score(details, player)
+				if !ok {
+					continue
+				}
+				if best == nil || score > best.score {
+					best = &awardCandidate{player: player, details: details, score: score}
+				}
+			}
+		}
+		if best == nil {
+			continue
+		}
+		awards = append(awards, award{
+			Title:     loc.T("award." + rule.key),
+			AccountID: best.player.AccountID,
+			HeroID:    best.player.HeroID,
+			MatchID:   best.details.MatchID,
+			Value:     rule.value(loc, best.score),
+		})
+	}
+	return awards
+}
+
+func formatAwards(loc locale, awards []award, names map[int64]string, heroes map[int]string) string {
+	if len(awards) == 0 {
+		return loc.T("awards.none")
+	}
+	lines := make([]string, 0, len(awards))
+	for _, a := range awards {
+		heroName := heroes[a.HeroID]
+		if heroName == "" {
+			heroName = fmt.Sprintf("Hero #%d", a.HeroID)
+		}
+		name := names[a.AccountID]
+		if name == "" {
+			name = fmt.Sprintf("Account %d", a.AccountID)
+		}
+		lines = append(lines, fmt.Sprintf("<b>%s:</b> %s (%s) — %s, <a href=\"https://www.opendota.com/matches/%d\">%s</a>",
+			escapeHTML(a.Title), escapeHTML(name), escapeHTML(heroName), escapeHTML(a.Value), a.MatchID, loc.T("awards.match")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// collectMatchDetails загружает детали всех матчей отслеживаемых игроков за
+// период фильтра, используя кэш деталей матчей.
+func collectMatchDetails(accountIDs []int64, filter matchFilter) ([]matchDetails, error) {
+	seen := make(map[int64]struct{})
+	var result []matchDetails
+	for _, accountID := range accountIDs {
+		matches, err := fetchFilteredMatches(accountID, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if _, ok := seen[m.MatchID]; ok {
+				continue
+			}
+			seen[m.MatchID] = struct{}{}
+			details, err := fetchCachedMatchDetails(m.MatchID)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, details)
+		}
+	}
+	return result, nil
+}
+
+func buildAwardsMessage(loc locale, accountIDs []int64, filter matchFilter, heroes map[int]string) (string, error) {
+	matches, err := collectMatchDetails(accountIDs, filter)
+	if err != nil {
+		return "", err
+	}
+	tracked := make(map[int64]struct{}, len(accountIDs))
+	names := make(map[int64]string, len(accountIDs))
+	for _, player := range loadTrackedPlayers(accountIDs) {
+		tracked[player.AccountID] = struct{}{}
+		names[player.AccountID] = player.Name
+	}
+	header := loc.T("awards.header", escapeHTML(filter.DescribeIn(loc)), loc.N(len(matches), "matches"))
+	return header + formatAwards(loc, computeAwards(loc, matches, tracked), names, heroes), nil
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputeAwards(t *testing.T) {
+	matches := []matchDetails{
+		{
+			MatchID:        2,
+			Duration:       3000,
+			RadiantWin:     false,
+			RadiantGoldAdv: []int{0, 5000, 12000, -3000},
+			Players: []matchDetailsPlayer{
+				{AccountID: 1, HeroID: 10, PlayerSlot: 128, HeroDamage: 30000, Deaths: 3, GPM: 700},
+				{AccountID: 99, HeroID: 11, PlayerSlot: 0, HeroDamage: 90000, Deaths: 20},
+			},
+		},
+		{
+			MatchID:  1,
+			Duration: 1800,
+			Players: []matchDetailsPlayer{
+				{AccountID: 2, HeroID: 20, PlayerSlot: 0, HeroDamage: 45000, Deaths: 12, GPM: 400, HeroHealing: 8000},
+			},
+		},
+	}
+	awards := computeAwards(newLocale(langRU), matches, map[int64]struct{}{1: {}, 2: {}})
+	byTitle := make(map[string]award, len(awards))
+	for _, a := range awards {
+		byTitle[a.Title] = a
+	}
+	// Неотслеживаемый игрок 99 не должен получать наград.
+	if a := byTitle["💥 Больше всего урона"]; a.AccountID != 2 || a.Value != "45.0k" {
+		t.Fatalf("unexpected damage award: %+v", a)
+	}
+	if a := byTitle["🍗 Кормилец"]; a.AccountID != 2 || a.MatchID != 1 || a.Value != "12 смертей" {
+		t.Fatalf("unexpected deaths award: %+v", a)
+	}
+	if a := byTitle["💰 Лучший GPM"]; a.AccountID != 1 || a.Value != "700 GPM" {
+		t.Fatalf("unexpected GPM award: %+v", a)
+	}
+	if a := byTitle["⏳ Самая долгая игра"]; a.MatchID != 2 || a.Value != "50:00" {
+		t.Fatalf("unexpected duration award: %+v", a)
+	}
+	// Dire отставал на 12k и выиграл.
+	if a := byTitle["🔄 Камбэк недели"]; a.AccountID != 1 || a.Value != "отыграно 12.0k золота" {
+		t.Fatalf("unexpected comeback award: %+v", a)
+	}
+}
+
+func TestComputeAwardsLocale(t *testing.T) {
+	matches := []matchDetails{{MatchID: 1, Players: []matchDetailsPlayer{{AccountID: 1, Deaths: 1}}}}
+	awards := computeAwards(newLocale(langEN), matches, map[int64]struct{}{1: {}})
+	if len(awards) != 1 || awards[0].Title != "🍗 Feeder" || awards[0].Value != "1 death" {
+		t.Fatalf("awards=%+v", awards)
+	}
+}
+
+func TestComebackScore_LostGame(t *testing.T) {
+	details := matchDetails{RadiantWin: true, RadiantGoldAdv: []int{-10000, 2000}}
+	if _, ok := comebackScore(details, matchDetailsPlayer{PlayerSlot: 128}); ok {
+		t.Fatal("losing side cannot have a comeback")
+	}
+	if score, ok := comebackScore(details, matchDetailsPlayer{PlayerSlot: 1}); !ok || score != 10000 {
+		t.Fatalf("score=%d ok=%v, want 10000", score, ok)
+	}
+}
+
+func TestFormatAwards(t *testing.T) {
+	out := formatAwards(newLocale(langRU), []award{{Title: "💥 Больше всего урона", AccountID: 1, HeroID: 2, MatchID: 5, Value: "45.0k"}},
+		map[int64]string{1: "<Nick>"}, map[int]string{2: "Axe"})
+	if !strings.Contains(out, "&lt;Nick&gt; (Axe)") || !strings.Contains(out, "opendota.com/matches/5") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	if got := formatAwards(newLocale(langRU), nil, nil, nil); got != "Нет матчей для наград" {
+		t.Fatalf("unexpected empty output: %q", got)
+	}
+}
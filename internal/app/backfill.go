@@ -0,0 +1,234 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// backfillProgress — ход загрузки истории одного аккаунта.
+type backfillProgress struct {
+	Index     int
+	Total     int
+	AccountID int64
+	// Matches — сколько новых матчей аккаунта загружено за этот запуск.
+	Matches int
+	Done    bool
+}
+
+// backfiller загружает полную историю матчей аккаунтов в локальное
+// хранилище страницами /players/{id}/matches. Докуда дошла загрузка,
+// хранится там же, поэтому прерванный backfill продолжается с той же
+// страницы, а для уже загруженных аккаунтов дозагружаются только новые
+// матчи. Запросы идут через общий лимит OpenDota; неудачная страница
+// повторяется с растущей паузой.
+type backfiller struct {
+	store      *localStore
+	fetch      func(accountID int64, offset int) ([]recentMatch, error)
+	report     func(backfillProgress)
+	retryDelay time.Duration
+}
+
+func newBackfiller(store *localStore, report func(backfillProgress)) *backfiller {
+	return &backfiller{store: store, fetch: fetchMatchPage, report: report, retryDelay: backfillRetryDelay}
+}
+
+func (b *backfiller) Run(accountIDs []int64) error {
+	for i, accountID := range accountIDs {
+		if err := b.account(backfillProgress{Index: i + 1, Total: len(accountIDs), AccountID: accountID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *backfiller) account(progress backfillProgress) error {
+	state := b.store.Backfill(progress.AccountID)
+	// Сначала старые матчи с места остановки...
+	for !state.Complete {
+		page, err := b.fetchPage(state.AccountID, state.Offset)
+		if err != nil {
+			return err
+		}
+		state.Offset += len(page)
+		state.Complete = len(page) < backfillPageSize
+		if _, err := b.put(&progress, state, page); err != nil {
+			return err
+		}
+	}
+	// ...затем сыгранные после начала загрузки: от самых новых до уже
+	// сохранённых.
+	for offset := 0; ; {
+		page, err := b.fetchPage(state.AccountID, offset)
+		if err != nil {
+			return err
+		}
+		known, err := b.put(&progress, state, page)
+		if err != nil {
+			return err
+		}
+		offset += len(page)
+		if known > 0 || len(page) < backfillPageSize {
+			break
+		}
+	}
+	progress.Done = true
+	b.report(progress)
+	return nil
+}
+
+// put сохраняет страницу и возвращает, сколько её матчей уже было известно.
+func (b *backfiller) put(progress *backfillProgress, state backfillState, page []recentMatch) (int, error) {
+	known, err := b.store.PutBackfillPage(state, page)
+	if err != nil {
+		return 0, fmt.Errorf("backfill %d: %w", state.AccountID, err)
+	}
+	progress.Matches += len(page) - known
+	slog.Info("backfill page", "account_id", state.AccountID, "offset", state.Offset, "matches", len(page), "new", len(page)-known)
+	if !progress.Done {
+		b.report(*progress)
+	}
+	return known, nil
+}
+
+func (b *backfiller) fetchPage(accountID int64, offset int) ([]recentMatch, error) {
+	for attempt := 1; ; attempt++ {
+		page, err := b.fetch(accountID, offset)
+		if err == nil {
+			return page, nil
+		}
+		if attempt > backfillRetries {
+			return nil, fmt.Errorf("backfill %d, offset %d: %w", accountID, offset, err)
+		}
+		delay := b.retryDelay * time.Duration(attempt)
+		slog.Warn("backfill page failed, retrying", "account_id", accountID, "offset", offset, "attempt", attempt, "retry_in", delay, "error", err)
+		time.Sleep(delay)
+	}
+}
+
+// backfillMessage показывает ход загрузки одним сообщением Telegram,
+// которое правится не чаще раза в backfillReportStep.
+type backfillMessage struct {
+	apiBase   string
+	chatID    int64
+	messageID int
+	sentAt    time.Time
+}
+
+func (m *backfillMessage) Update(text string, force bool) {
+	if !force && time.Since(m.sentAt) < backfillReportStep {
+		return
+	}
+	m.sentAt = time.Now()
+	var err error
+	if m.messageID == 0 {
+		var sent telegramMessage
+		err = callTelegram(m.apiBase, "sendMessage", map[string]any{"chat_id": m.chatID, "text": text}, &sent)
+		m.messageID = sent.MessageID
+	} else {
+		err = editTelegramMessage(m.apiBase, m.chatID, m.messageID, text, "", nil)
+	}
+	if err != nil {
+		slog.Warn("backfill progress not sent", "chat_id", m.chatID, "error", err)
+	}
+}
+
+// backfillRestart — аргумент /backfill, с которым история загружается заново.
+const backfillRestart = "restart"
+
+func parseBackfillArgs(raw []string) (commandArgs, error) {
+	switch {
+	case len(raw) == 0:
+		return commandArgs{Raw: raw}, nil
+	case len(raw) == 1 && strings.EqualFold(raw[0], backfillRestart):
+		return commandArgs{Raw: raw, Text: backfillRestart}, nil
+	}
+	return commandArgs{}, newLocalizedError("usage.backfill")
+}
+
+// handleBackfillCommand загружает историю в процессе бота, в ту же
+// matchHistory, из которой бот отвечает: FreshMatches включается сразу по
+// окончании, без перезапуска. Ход загрузки виден одним сообщением в чате.
+func handleBackfillCommand(bot *telegramBot, req commandRequest) error {
+	if !bot.backfilling.CompareAndSwap(false, true) {
+		return newLocalizedError("err.backfill_running")
+	}
+	accountIDs := bot.accountStore.Get()
+	message := &backfillMessage{apiBase: bot.apiBase, chatID: req.ChatID}
+	go func() {
+		defer bot.backfilling.Store(false)
+		err := runBackfill(matchHistory, accountIDs, req.Args.Text == backfillRestart, req.Locale, message, nil)
+		if err != nil {
+			slog.Warn("backfill failed", "chat_id", req.ChatID, "error", err)
+		}
+	}()
+	return nil
+}
+
+// runBackfill загружает историю accountIDs в store; restart сбрасывает
+// сохранённое состояние загрузки. С message ход загрузки виден в Telegram,
+// done вызывается после каждого загруженного аккаунта.
+func runBackfill(store *localStore, accountIDs []int64, restart bool, loc locale, message *backfillMessage, done func(backfillProgress)) error {
+	if restart {
+		for _, accountID := range accountIDs {
+			if _, err := store.PutBackfillPage(backfillState{AccountID: accountID}, nil); err != nil {
+				return err
+			}
+		}
+	}
+	total := 0
+	err := newBackfiller(store, func(p backfillProgress) {
+		if p.Done {
+			total += p.Matches
+			if done != nil {
+				done(p)
+			}
+		}
+		if message != nil {
+			message.Update(loc.T("backfill.progress", p.Index, p.Total, p.AccountID, loc.N(p.Matches, "matches")), false)
+		}
+	}).Run(accountIDs)
+	if message != nil {
+		text := loc.T("backfill.done", len(accountIDs), loc.N(total, "matches"))
+		if err != nil {
+			text = loc.T("backfill.failed", loc.ErrorText(err))
+		}
+		message.Update(text, true)
+	}
+	return err
+}
+
+// runBackfillCLI загружает полную историю отслеживаемых аккаунтов в
+// локальное хранилище; с -chat ход загрузки виден в Telegram.
+func runBackfillCLI(args []string, stdout io.Writer) error {
+	var opts cliOptions
+	var restart bool
+	var chatID int64
+	flags := newCLIFlags("backfill", "[флаги]", &opts, false)
+	flags.BoolVar(&restart, "restart", false, "загрузить историю заново, а не с места остановки")
+	flags.Int64Var(&chatID, "chat", 0, "чат Telegram, где показывать ход загрузки")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	opts.writer = true
+	cfg, accountStore, err := opts.load()
+	if errors.Is(err, errStoreLocked) {
+		return fmt.Errorf("%w; пока бот запущен, загружайте историю командой /backfill", err)
+	}
+	if err != nil {
+		return err
+	}
+	var message *backfillMessage
+	if chatID != 0 {
+		if cfg.Telegram.Token == "" {
+			return fmt.Errorf("для -chat нужен telegram.token в конфиге или %s", telegramTokenEnv)
+		}
+		message = &backfillMessage{apiBase: fmt.Sprintf(telegramBaseURL, cfg.Telegram.Token), chatID: chatID}
+	}
+	return runBackfill(matchHistory, accountStore.Get(), restart, localeFor(chatID), message, func(p backfillProgress) {
+		fmt.Fprintf(stdout, "[%d/%d] %d: новых матчей %d\n", p.Index, p.Total, p.AccountID, p.Matches)
+	})
+}
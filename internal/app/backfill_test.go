@@ -0,0 +1,140 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeHistory отдаёт матчи страницами, как /players/{id}/matches: от новых
+// к старым, по backfillPageSize с offset.
+type fakeHistory struct {
+	matches  []recentMatch
+	requests []int
+	failAt   int
+}
+
+func newFakeHistory(n int) *fakeHistory {
+	h := &fakeHistory{failAt: -1}
+	for i := n; i >= 1; i-- {
+		h.matches = append(h.matches, recentMatch{MatchID: int64(i), StartTime: int64(i) * 3600})
+	}
+	return h
+}
+
+func (h *fakeHistory) fetch(_ int64, offset int) ([]recentMatch, error) {
+	h.requests = append(h.requests, offset)
+	if offset == h.failAt {
+		return nil, errors.New("429 Too Many Requests")
+	}
+	end := min(offset+backfillPageSize, len(h.matches))
+	if offset >= end {
+		return []recentMatch{}, nil
+	}
+	return h.matches[offset:end], nil
+}
+
+func (h *fakeHistory) play(n int) {
+	last := h.matches[0].MatchID
+	for i := 1; i <= n; i++ {
+		h.matches = append([]recentMatch{{MatchID: last + int64(i), StartTime: (last + int64(i)) * 3600}}, h.matches...)
+	}
+}
+
+func TestBackfillResume(t *testing.T) {
+	store, _ := withMatchHistory(t)
+	history := newFakeHistory(backfillPageSize*2 + 100)
+	history.failAt = backfillPageSize
+	var reports []backfillProgress
+	b := &backfiller{store: store, fetch: history.fetch, report: func(p backfillProgress) { reports = append(reports, p) }}
+
+	// Вторая страница не загрузилась даже после повторов.
+	if err := b.Run([]int64{1}); err == nil {
+		t.Fatal("expected error")
+	}
+	if state := store.Backfill(1); state.Offset != backfillPageSize || state.Complete {
+		t.Fatalf("state=%+v", state)
+	}
+	if got := len(history.requests); got != 2+backfillRetries {
+		t.Fatalf("requests=%v", history.requests)
+	}
+
+	// Повторный запуск продолжает со второй страницы.
+	history.failAt, history.requests, reports = -1, nil, nil
+	if err := b.Run([]int64{1}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if history.requests[0] != backfillPageSize {
+		t.Fatalf("requests=%v", history.requests)
+	}
+	if state := store.Backfill(1); !state.Complete {
+		t.Fatalf("state=%+v", state)
+	}
+	if stats := store.Stats(); stats.Rows != len(history.matches) || stats.Complete != 1 {
+		t.Fatalf("stats=%+v", stats)
+	}
+	if last := reports[len(reports)-1]; !last.Done || last.Matches != backfillPageSize+100 {
+		t.Fatalf("report=%+v", last)
+	}
+
+	// Для загруженной истории дозагружаются только новые матчи.
+	history.play(3)
+	history.requests, reports = nil, nil
+	if err := b.Run([]int64{1}); err != nil {
+		t.Fatalf("top-up: %v", err)
+	}
+	if len(history.requests) != 1 || reports[len(reports)-1].Matches != 3 {
+		t.Fatalf("requests=%v, reports=%+v", history.requests, reports)
+	}
+}
+
+func TestBackfillFreshMatches(t *testing.T) {
+	store, _ := withMatchHistory(t)
+	history := newFakeHistory(30)
+	now := time.Now()
+	if _, ok := store.FreshMatches(1, matchFilter{}, now); ok {
+		t.Fatal("history is not loaded yet")
+	}
+	b := &backfiller{store: store, fetch: history.fetch, report: func(backfillProgress) {}}
+	if err := b.Run([]int64{1}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	matches, ok := store.FreshMatches(1, matchFilter{Limit: 5}, now)
+	if !ok || len(matches) != 5 || matches[0].MatchID != 30 {
+		t.Fatalf("fresh=%+v, %v", matches, ok)
+	}
+	if _, ok := store.FreshMatches(1, matchFilter{}, now.Add(storeSyncMaxAge+time.Minute)); ok {
+		t.Fatal("stale history answered locally")
+	}
+
+	// Последние матчи без пересечения с историей — пропуск, история больше
+	// не считается свежей.
+	var recent []recentMatch
+	for i := 0; i < recentMatchesCount; i++ {
+		recent = append(recent, recentMatch{MatchID: int64(1000 + i), StartTime: int64(1000+i) * 3600})
+	}
+	store.PutRecent(1, recent)
+	if _, ok := store.FreshMatches(1, matchFilter{}, now); ok {
+		t.Fatal("history with a gap answered locally")
+	}
+	store.PutRecent(1, recent)
+	if _, ok := store.FreshMatches(1, matchFilter{}, time.Now()); !ok {
+		t.Fatal("overlapping recent matches should sync history")
+	}
+}
+
+func TestParseBackfillArgs(t *testing.T) {
+	if args, err := parseBackfillArgs(nil); err != nil || args.Text != "" {
+		t.Fatalf("args=%+v, %v", args, err)
+	}
+	if args, err := parseBackfillArgs([]string{"Restart"}); err != nil || args.Text != backfillRestart {
+		t.Fatalf("args=%+v, %v", args, err)
+	}
+	if _, err := parseBackfillArgs([]string{"all"}); err == nil {
+		t.Fatal("expected usage error")
+	}
+	// Загрузка идёт в процессе бота, поэтому команда только для админов.
+	if cmd, ok := defaultCommands().Lookup("backfill"); !ok || cmd.Permission != permissionAdmin {
+		t.Fatalf("cmd=%+v, %v", cmd, ok)
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// ttlCache хранит не больше maxEntries значений: просроченные удаляются при
+// чтении и когда кэш заполнен, а если места всё равно нет — вытесняется
+// значение, которое истекает раньше всех.
+type ttlCache[K comparable, V any] struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[K]cacheEntry[V]
+}
+
+func newTTLCache[K comparable, V any](ttl time.Duration, maxEntries int) *ttlCache[K, V] {
+	return &ttlCache[K, V]{ttl: ttl, maxEntries: maxEntries, entries: make(map[K]cacheEntry[V])}
+}
+
+func (c *ttlCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && c.expired(entry, time.Now()) {
+		delete(c.entries, key)
+		ok = false
+	}
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+	c.entries[key] = cacheEntry[V]{value: value, expires: now.Add(c.ttl)}
+}
+
+func (c *ttlCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *ttlCache[K, V]) expired(entry cacheEntry[V], now time.Time) bool {
+	return c.ttl > 0 && now.After(entry.expires)
+}
+
+// evictLocked освобождает место хотя бы под одно значение.
+func (c *ttlCache[K, V]) evictLocked(now time.Time) {
+	var oldest K
+	var oldestExpires time.Time
+	for key, entry := range c.entries {
+		if c.expired(entry, now) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestExpires.IsZero() || entry.expires.Before(oldestExpires) {
+			oldest, oldestExpires = key, entry.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, oldest)
+	}
+}
+
+// GetOrLoad возвращает значение из кэша или загружает его через load.
+func (c *ttlCache[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.Set(key, value)
+	return value, nil
+}
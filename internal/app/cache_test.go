@@ -0,0 +1,68 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTTLCache_GetOrLoad(t *testing.T) {
+	cache := newTTLCache[int, string](time.Minute, 0)
+	calls := 0
+	load := func() (string, error) {
+		calls++
+		return "value", nil
+	}
+	// Второй вызов должен брать значение из кэша.
+	for i := 0; i < 2; i++ {
+		got, err := cache.GetOrLoad(1, load)
+		if err != nil || got != "value" {
+			t.Fatalf("GetOrLoad=%q, %v", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("calls=%d, want 1", calls)
+	}
+}
+
+func TestTTLCache_ErrorNotCached(t *testing.T) {
+	cache := newTTLCache[int, string](time.Minute, 0)
+	if _, err := cache.GetOrLoad(1, func() (string, error) { return "", errors.New("fail") }); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, ok := cache.Get(1); ok {
+		t.Fatal("failed load must not be cached")
+	}
+}
+
+func TestTTLCache_Expiry(t *testing.T) {
+	cache := newTTLCache[int, string](time.Minute, 0)
+	cache.Set(1, "old")
+	cache.mu.Lock()
+	cache.entries[1] = cacheEntry[string]{value: "old", expires: time.Now().Add(-time.Second)}
+	cache.mu.Unlock()
+	// Просроченное значение не возвращается и удаляется из памяти.
+	if _, ok := cache.Get(1); ok {
+		t.Fatal("expired value returned")
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("len=%d, want 0", cache.Len())
+	}
+}
+
+func TestTTLCache_MaxEntries(t *testing.T) {
+	cache := newTTLCache[int, string](time.Minute, 2)
+	cache.Set(1, "a")
+	cache.Set(2, "b")
+	cache.Set(3, "c")
+	if cache.Len() != 2 {
+		t.Fatalf("len=%d, want 2", cache.Len())
+	}
+	// Вытесняется значение, которое истекает раньше всех, — первое.
+	if _, ok := cache.Get(1); ok {
+		t.Fatal("oldest value was not evicted")
+	}
+	if got, ok := cache.Get(3); !ok || got != "c" {
+		t.Fatalf("Get(3)=%q, %v", got, ok)
+	}
+}
@@ -0,0 +1,225 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"log/slog"
+	"strings"
+	"sync"
+
+	easykatka "easyKatka"
+)
+
+// matchCard — данные для картинки с итогом матча.
+type matchCard struct {
+	Portrait image.Image
+	Win      bool
+	Kills    int
+	Deaths   int
+	Assists  int
+	Duration int
+	Items    []string
+}
+
+const (
+	cardWidth        = 640
+	cardHeight       = 200
+	cardBannerHeight = 48
+	cardPortraitSize = 128
+	cardPadding      = 16
+	cardItemLines    = 3
+)
+
+var cardPanel = color.RGBA{R: 36, G: 39, B: 46, A: 255}
+
+var loadBotLogo = sync.OnceValues(func() (image.Image, error) {
+	return loadEmbeddedPNG("images/botlogo.png")
+})
+
+// loadHeroPortrait читает вшитый портрет героя. Для героев, которых ещё нет
+// в images/heroes, возвращает ошибку с fs.ErrNotExist.
+func loadHeroPortrait(slug string) (image.Image, error) {
+	if slug == "" {
+		return nil, fmt.Errorf("hero portrait: %w", fs.ErrNotExist)
+	}
+	return loadEmbeddedPNG("images/heroes/" + slug + ".png")
+}
+
+func loadEmbeddedPNG(path string) (image.Image, error) {
+	file, err := easykatka.Images.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// buildMatchCardPNG собирает карточку для уведомления. Предметы берутся из
+// деталей матча; если их не удалось загрузить, карточка рисуется без них.
+func buildMatchCardPNG(msg matchNotification) ([]byte, error) {
+	slugs, err := fetchCachedHeroSlugs()
+	if err != nil {
+		return nil, err
+	}
+	portrait, err := loadHeroPortrait(slugs[msg.Match.HeroID])
+	if err != nil {
+		return nil, err
+	}
+	card := matchCard{
+		Portrait: portrait,
+		Win:      matchWin(msg.Match),
+		Kills:    msg.Match.Kills,
+		Deaths:   msg.Match.Deaths,
+		Assists:  msg.Match.Assists,
+		Duration: msg.Match.Duration,
+	}
+	if details, err := fetchCachedMatchDetails(msg.MatchID); err == nil {
+		if player := findPlayerInMatch(details, msg.AccountID); player != nil {
+			if itemNames, err := fetchCachedItemNames(); err == nil {
+				card.Items = collectPlayerItems(*player, itemNames)
+			}
+		}
+	}
+	return renderMatchCardPNG(card)
+}
+
+func renderMatchCardPNG(card matchCard) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	fillRect(img, img.Bounds(), chartBackground)
+
+	banner, result := chartDeaths, "LOSS"
+	if card.Win {
+		banner, result = chartKills, "WIN"
+	}
+	fillRect(img, image.Rect(0, 0, cardWidth, cardBannerHeight), banner)
+	drawTextScaled(img, cardPadding, 10, result, chartText, 4)
+	if logo, err := loadBotLogo(); err == nil {
+		size := cardBannerHeight - 8
+		drawImageScaled(img, image.Rect(cardWidth-cardPadding-size, 4, cardWidth-cardPadding, 4+size), logo)
+	}
+
+	portraitTop := cardBannerHeight + 12
+	portrait := image.Rect(cardPadding, portraitTop, cardPadding+cardPortraitSize, portraitTop+cardPortraitSize)
+	fillRect(img, portrait, cardPanel)
+	if card.Portrait != nil {
+		drawImageScaled(img, portrait, card.Portrait)
+	}
+
+	left := portrait.Max.X + cardPadding
+	drawTextScaled(img, left, portraitTop+4, fmt.Sprintf("K/D/A %d/%d/%d", card.Kills, card.Deaths, card.Assists), chartText, 3)
+	drawTextScaled(img, left, portraitTop+36, "TIME "+formatDuration(card.Duration), chartText, 3)
+	maxChars := (cardWidth - left - cardPadding) / (6 * chartFontScale)
+	for i, line := range wrapCardText(strings.ToUpper(strings.Join(card.Items, ", ")), maxChars, cardItemLines) {
+		drawText(img, left, portraitTop+72+i*18, line, chartReference)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode card: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// wrapCardText переносит текст по словам; лишние строки отбрасываются,
+// последняя видимая заканчивается многоточием.
+func wrapCardText(text string, width int, maxLines int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		if current != "" && runeLen(current)+1+runeLen(word) > width {
+			lines = append(lines, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := []rune(lines[maxLines-1])
+		if len(last) > width-3 {
+			last = last[:width-3]
+		}
+		lines[maxLines-1] = string(last) + "..."
+	}
+	for i, line := range lines {
+		if runeLen(line) > width {
+			lines[i] = string([]rune(line)[:width])
+		}
+	}
+	return lines
+}
+
+// drawImageScaled вписывает src в rect, усредняя пиксели исходника, и
+// накладывает его поверх фона с учётом прозрачности.
+func drawImageScaled(dst *image.RGBA, rect image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Empty() || rect.Empty() {
+		return
+	}
+	for y := 0; y < rect.Dy(); y++ {
+		sy0 := sb.Min.Y + y*sb.Dy()/rect.Dy()
+		sy1 := max(sy0+1, sb.Min.Y+(y+1)*sb.Dy()/rect.Dy())
+		for x := 0; x < rect.Dx(); x++ {
+			sx0 := sb.Min.X + x*sb.Dx()/rect.Dx()
+			sx1 := max(sx0+1, sb.Min.X+(x+1)*sb.Dx()/rect.Dx())
+			var r, g, b, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+pr, g+pg, b+pb, a+pa
+					n++
+				}
+			}
+			r, g, b, a = r/n, g/n, b/n, a/n
+			px, py := rect.Min.X+x, rect.Min.Y+y
+			if !(image.Point{X: px, Y: py}).In(dst.Bounds()) {
+				continue
+			}
+			under := dst.RGBAAt(px, py)
+			inv := 0xffff - a
+			blend := func(over uint32, base uint8) uint8 {
+				return uint8((over + uint32(base)*0x101*inv/0xffff) >> 8)
+			}
+			dst.SetRGBA(px, py, color.RGBA{R: blend(r, under.R), G: blend(g, under.G), B: blend(b, under.B), A: 255})
+		}
+	}
+}
+
+// sendMatchNotification отправляет карточку с текстом в подписи и
+// возвращает отправленное сообщение. Если карточку собрать не удалось
+// (например, нет портрета нового героя), уходит обычное текстовое сообщение.
+func sendMatchNotification(apiBase string, chatID int64, loc locale, msg matchNotification) (telegramMessage, error) {
+	replyMarkup := buildMatchDetailsMarkup(loc, msg)
+	var sent telegramMessage
+	if msg.Match.HeroID != 0 {
+		card, err := buildMatchCardPNG(msg)
+		if err == nil {
+			filename := fmt.Sprintf("match_%d.png", msg.MatchID)
+			err = postTelegramFile(apiBase, "sendPhoto", "photo", chatID, filename, card, msg.Text, "", replyMarkup, &sent)
+			return sent, err
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("match card failed", "match_id", msg.MatchID, "account_id", msg.AccountID, "error", err)
+		}
+	}
+	payload := map[string]any{"chat_id": chatID, "text": msg.Text}
+	if replyMarkup != nil {
+		payload["reply_markup"] = replyMarkup
+	}
+	err := callTelegram(apiBase, "sendMessage", payload, &sent)
+	return sent, err
+}
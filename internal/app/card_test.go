@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"io/fs"
+	"testing"
+)
+
+func TestLoadHeroPortrait(t *testing.T) {
+	img, err := loadHeroPortrait("antimage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if img.Bounds().Empty() {
+		t.Fatal("empty portrait")
+	}
+	// Портрета нового героя нет — уведомление должно уйти текстом.
+	if _, err := loadHeroPortrait("new_hero"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err=%v, want fs.ErrNotExist", err)
+	}
+	if _, err := loadHeroPortrait(""); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err=%v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestRenderMatchCardPNG(t *testing.T) {
+	portrait, err := loadHeroPortrait("axe")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := renderMatchCardPNG(matchCard{
+		Portrait: portrait,
+		Win:      true,
+		Kills:    12,
+		Deaths:   3,
+		Assists:  9,
+		Duration: 2345,
+		Items:    []string{"Blink Dagger", "Black King Bar", "Blade Mail"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if img.Bounds().Dx() != cardWidth || img.Bounds().Dy() != cardHeight {
+		t.Fatalf("size=%v", img.Bounds())
+	}
+	// Баннер победы зелёный.
+	r, g, _, _ := img.At(cardWidth/2, 2).RGBA()
+	if g <= r {
+		t.Fatalf("banner is not green: r=%d g=%d", r, g)
+	}
+}
+
+func TestWrapCardText(t *testing.T) {
+	lines := wrapCardText("BLINK DAGGER, BLACK KING BAR, BLADE MAIL", 14, 2)
+	if len(lines) != 2 {
+		t.Fatalf("lines=%q", lines)
+	}
+	if lines[0] != "BLINK DAGGER," {
+		t.Fatalf("first line=%q", lines[0])
+	}
+	if got := lines[1]; len(got) > 14 || got[len(got)-3:] != "..." {
+		t.Fatalf("last line=%q", got)
+	}
+}
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strconv"
+)
+
+type chartSeries struct {
+	Values []float64
+	Color  color.RGBA
+}
+
+type chartPanel struct {
+	Title     string
+	Min       float64
+	Max       float64
+	Lines     []chartSeries
+	Bars      []chartSeries
+	Reference *float64
+}
+
+var (
+	chartBackground = color.RGBA{R: 24, G: 26, B: 31, A: 255}
+	chartGrid       = color.RGBA{R: 58, G: 62, B: 72, A: 255}
+	chartText       = color.RGBA{R: 210, G: 214, B: 222, A: 255}
+	chartWinrate    = color.RGBA{R: 255, G: 196, B: 0, A: 255}
+	chartKills      = color.RGBA{R: 92, G: 184, B: 92, A: 255}
+	chartDeaths     = color.RGBA{R: 217, G: 83, B: 79, A: 255}
+	chartAssists    = color.RGBA{R: 91, G: 155, B: 213, A: 255}
+	chartGPM        = color.RGBA{R: 240, G: 173, B: 78, A: 255}
+	chartReference  = color.RGBA{R: 120, G: 124, B: 134, A: 255}
+)
+
+const (
+	chartWidth       = 900
+	chartPanelHeight = 240
+	chartMarginLeft  = 56
+	chartMarginRight = 16
+	chartMarginTop   = 28
+	chartMarginBot   = 24
+	chartFontScale   = 2
+	chartRollingSize = 10
+)
+
+// chartGlyphs — растровый шрифт 5x7: цифры, латиница и немного пунктуации.
+// Остальные символы рисуются пробелом.
+var chartGlyphs = map[rune][7]uint8{
+	'0':  {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1':  {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3':  {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4':  {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5':  {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6':  {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8':  {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9':  {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'%':  {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+	'/':  {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'.':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	'-':  {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	':':  {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'\'': {0x0C, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00},
+	'(':  {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')':  {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'+':  {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	',':  {0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08},
+	'A':  {0x0E, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'B':  {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C':  {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D':  {0x1E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x1E},
+	'E':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G':  {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H':  {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I':  {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J':  {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K':  {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L':  {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M':  {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N':  {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O':  {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P':  {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q':  {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R':  {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S':  {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T':  {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W':  {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X':  {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y':  {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+}
+
+// buildPlayerChartPanels готовит панели графиков по матчам (от новых к старым):
+// скользящий винрейт, K/D/A по матчам и GPM.
+func buildPlayerChartPanels(matches []recentMatch) ([]chartPanel, error) {
+	if len(matches) < 2 {
+		return nil, newLocalizedError("err.chart_matches")
+	}
+	ordered := make([]recentMatch, len(matches))
+	for i, m := range matches {
+		ordered[len(matches)-1-i] = m
+	}
+
+	window := min(chartRollingSize, len(ordered))
+	winrate := make([]float64, 0, len(ordered))
+	wins := 0
+	for i, m := range ordered {
+		if matchWin(m) {
+			wins++
+		}
+		if i >= window && matchWin(ordered[i-window]) {
+			wins--
+		}
+		games := min(i+1, window)
+		winrate = append(winrate, float64(wins)*100/float64(games))
+	}
+
+	kills := make([]float64, len(ordered))
+	deaths := make([]float64, len(ordered))
+	assists := make([]float64, len(ordered))
+	gpm := make([]float64, len(ordered))
+	maxKDA := 1.0
+	minGPM, maxGPM := math.MaxFloat64, 0.0
+	for i, m := range ordered {
+		kills[i] = float64(m.Kills)
+		deaths[i] = float64(m.Deaths)
+		assists[i] = float64(m.Assists)
+		gpm[i] = float64(m.GPM)
+		maxKDA = math.Max(maxKDA, math.Max(kills[i], math.Max(deaths[i], assists[i])))
+		minGPM = math.Min(minGPM, gpm[i])
+		maxGPM = math.Max(maxGPM, gpm[i])
+	}
+	minGPM = math.Floor(minGPM/100) * 100
+	maxGPM = math.Ceil(maxGPM/100)*100 + 100
+
+	half := 50.0
+	return []chartPanel{
+		{
+			Title:     "WR %",
+			Min:       0,
+			Max:       100,
+			Lines:     []chartSeries{{Values: winrate, Color: chartWinrate}},
+			Reference: &half,
+		},
+		{
+			Title: "K/D/A",
+			Min:   0,
+			Max:   math.Ceil(maxKDA/4) * 4,
+			Bars: []chartSeries{
+				{Values: kills, Color: chartKills},
+				{Values: deaths, Color: chartDeaths},
+				{Values: assists, Color: chartAssists},
+			},
+		},
+		{
+			Title: "GPM",
+			Min:   minGPM,
+			Max:   maxGPM,
+			Lines: []chartSeries{{Values: gpm, Color: chartGPM}},
+		},
+	}, nil
+}
+
+func renderChartPNG(panels []chartPanel) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartPanelHeight*len(panels)))
+	fillRect(img, img.Bounds(), chartBackground)
+	for i, panel := range panels {
+		drawChartPanel(img, image.Rect(0, i*chartPanelHeight, chartWidth, (i+1)*chartPanelHeight), panel)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func drawChartPanel(img *image.RGBA, bounds image.Rectangle, panel chartPanel) {
+	plot := image.Rect(
+		bounds.Min.X+chartMarginLeft,
+		bounds.Min.Y+chartMarginTop,
+		bounds.Max.X-chartMarginRight,
+		bounds.Max.Y-chartMarginBot,
+	)
+	drawText(img, bounds.Min.X+chartMarginLeft, bounds.Min.Y+6, panel.Title, chartText)
+	span := panel.Max - panel.Min
+	if span <= 0 {
+		span = 1
+	}
+	toY := func(value float64) int {
+		ratio := (value - panel.Min) / span
+		return plot.Max.Y - int(math.Round(ratio*float64(plot.Dy())))
+	}
+
+	const gridLines = 4
+	for i := 0; i <= gridLines; i++ {
+		value := panel.Min + span*float64(i)/gridLines
+		y := toY(value)
+		drawLine(img, plot.Min.X, y, plot.Max.X, y, chartGrid)
+		label := strconv.Itoa(int(math.Round(value)))
+		drawText(img, plot.Min.X-8-textWidth(label), y-3*chartFontScale, label, chartText)
+	}
+	if panel.Reference != nil {
+		y := toY(*panel.Reference)
+		for x := plot.Min.X; x < plot.Max.X; x += 8 {
+			drawLine(img, x, y, min(x+4, plot.Max.X), y, chartReference)
+		}
+	}
+
+	count := 0
+	for _, s := range append(append([]chartSeries(nil), panel.Lines...), panel.Bars...) {
+		count = max(count, len(s.Values))
+	}
+	if count == 0 {
+		return
+	}
+	step := float64(plot.Dx()) / float64(count)
+	centerX := func(i int) int {
+		return plot.Min.X + int(step*float64(i)+step/2)
+	}
+
+	if len(panel.Bars) > 0 {
+		groupWidth := step * 0.8
+		barWidth := max(1, int(groupWidth/float64(len(panel.Bars))))
+		for i := 0; i < count; i++ {
+			left := centerX(i) - int(groupWidth/2)
+			for j, s := range panel.Bars {
+				if i >= len(s.Values) {
+					continue
+				}
+				x := left + j*barWidth
+				fillRect(img, image.Rect(x, toY(s.Values[i]), x+barWidth-1, plot.Max.Y), s.Color)
+			}
+		}
+	}
+	for _, s := range panel.Lines {
+		for i := 1; i < len(s.Values); i++ {
+			drawThickLine(img, centerX(i-1), toY(s.Values[i-1]), centerX(i), toY(s.Values[i]), s.Color)
+		}
+		for i, v := range s.Values {
+			x, y := centerX(i), toY(v)
+			fillRect(img, image.Rect(x-2, y-2, x+3, y+3), s.Color)
+		}
+	}
+
+	labelEvery := max(1, count/10)
+	for i := 0; i < count; i += labelEvery {
+		label := strconv.Itoa(i + 1)
+		drawText(img, centerX(i)-textWidth(label)/2, plot.Max.Y+6, label, chartText)
+	}
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	rect = rect.Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		if (image.Point{X: x0, Y: y0}).In(img.Bounds()) {
+			img.SetRGBA(x0, y0, c)
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func drawThickLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	drawLine(img, x0, y0, x1, y1, c)
+	drawLine(img, x0, y0+1, x1, y1+1, c)
+	drawLine(img, x0+1, y0, x1+1, y1, c)
+}
+
+func drawText(img *image.RGBA, x, y int, text string, c color.RGBA) {
+	drawTextScaled(img, x, y, text, c, chartFontScale)
+}
+
+func drawTextScaled(img *image.RGBA, x, y int, text string, c color.RGBA, scale int) {
+	for _, r := range text {
+		glyph, ok := chartGlyphs[r]
+		if ok {
+			for row, bits := range glyph {
+				for col := 0; col < 5; col++ {
+					if bits&(1<<(4-col)) == 0 {
+						continue
+					}
+					px := x + col*scale
+					py := y + row*scale
+					fillRect(img, image.Rect(px, py, px+scale, py+scale), c)
+				}
+			}
+		}
+		x += 6 * scale
+	}
+}
+
+func textWidth(text string) int {
+	return runeLen(text) * 6 * chartFontScale
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestBuildPlayerChartPanels_RollingWinrate(t *testing.T) {
+	// Матчи идут от новых к старым: старейший — победа, затем два поражения.
+	matches := []recentMatch{
+		{RadiantWin: false, GPM: 400},
+		{RadiantWin: false, GPM: 500},
+		{RadiantWin: true, GPM: 600},
+	}
+	panels, err := buildPlayerChartPanels(matches)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(panels) != 3 {
+		t.Fatalf("panels=%d, want 3", len(panels))
+	}
+	want := []float64{100, 50, 100.0 / 3}
+	got := panels[0].Lines[0].Values
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("winrate[%d]=%f, want %f", i, got[i], want[i])
+		}
+	}
+	gpm := panels[2]
+	if gpm.Lines[0].Values[0] != 600 || gpm.Min != 400 || gpm.Max != 700 {
+		t.Fatalf("unexpected GPM panel: min=%f max=%f values=%v", gpm.Min, gpm.Max, gpm.Lines[0].Values)
+	}
+}
+
+func TestBuildPlayerChartPanels_TooFewMatches(t *testing.T) {
+	if _, err := buildPlayerChartPanels([]recentMatch{{}}); err == nil {
+		t.Fatal("expected error for a single match")
+	}
+}
+
+func TestRenderChartPNG(t *testing.T) {
+	matches := make([]recentMatch, 0, 20)
+	for i := 0; i < 20; i++ {
+		matches = append(matches, recentMatch{RadiantWin: i%3 == 0, Kills: i % 7, Deaths: i % 5, Assists: i % 11, GPM: 300 + i*15})
+	}
+	panels, err := buildPlayerChartPanels(matches)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := renderChartPNG(panels)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if img.Bounds().Dx() != chartWidth || img.Bounds().Dy() != chartPanelHeight*len(panels) {
+		t.Fatalf("unexpected image size: %v", img.Bounds())
+	}
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// chatSettings — настройки чата, заданные командами бота.
+type chatSettings struct {
+	Lang     string `json:"lang,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// chatSettingsStore хранит настройки чатов в data/chats.json.
+type chatSettingsStore struct {
+	mu    sync.RWMutex
+	path  string
+	chats map[int64]chatSettings
+}
+
+func newChatSettingsStore() *chatSettingsStore {
+	return &chatSettingsStore{chats: make(map[int64]chatSettings)}
+}
+
+// Load читает chats.json; отсутствие файла не ошибка.
+func (s *chatSettingsStore) Load(path string) error {
+	chats := make(map[int64]chatSettings)
+	raw, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("read chat settings: %w", err)
+	default:
+		var stored map[string]chatSettings
+		if err := json.Unmarshal(raw, &stored); err != nil {
+			return fmt.Errorf("parse chat settings: %w", err)
+		}
+		for key, settings := range stored {
+			id, err := strconv.ParseInt(key, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parse chat settings: некорректный chat_id %q", key)
+			}
+			chats[id] = settings
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.path = path
+	s.chats = chats
+	return nil
+}
+
+func (s *chatSettingsStore) Get(chatID int64) chatSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.chats[chatID]
+}
+
+// Update меняет настройки чата через fn и сохраняет файл; при ошибке
+// записи настройки остаются прежними.
+func (s *chatSettingsStore) Update(chatID int64, fn func(settings *chatSettings)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, had := s.chats[chatID]
+	settings := previous
+	fn(&settings)
+	if settings == (chatSettings{}) {
+		delete(s.chats, chatID)
+	} else {
+		s.chats[chatID] = settings
+	}
+	if err := s.saveLocked(); err != nil {
+		if had {
+			s.chats[chatID] = previous
+		} else {
+			delete(s.chats, chatID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *chatSettingsStore) saveLocked() error {
+	if s.path == "" {
+		return fmt.Errorf("файл настроек чатов не задан")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create chat settings dir: %w", err)
+	}
+	stored := make(map[string]chatSettings, len(s.chats))
+	for id, settings := range s.chats {
+		stored[strconv.FormatInt(id, 10)] = settings
+	}
+	raw, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal chat settings: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write chat settings: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("write chat settings: %w", err)
+	}
+	return nil
+}
+
+// localeFor — язык и часовой пояс чата из /lang и /tz; не заданные в чате
+// берутся из конфига.
+func localeFor(chatID int64) locale {
+	settings := chatPrefs.Get(chatID)
+	loc := defaultLocale()
+	if settings.Lang != "" {
+		loc = newLocale(settings.Lang).In(loc.Location())
+	}
+	if settings.Timezone != "" {
+		if tz, err := loadTimezone(settings.Timezone); err == nil {
+			loc = loc.In(tz)
+		}
+	}
+	return loc
+}
+
+// loadTimezone загружает IANA-пояс; "UTC" и "Local" тоже подходят.
+func loadTimezone(name string) (*time.Location, error) {
+	tz, err := time.LoadLocation(strings.TrimSpace(name))
+	if err != nil {
+		return nil, newLocalizedError("err.tz", name)
+	}
+	return tz, nil
+}
+
+// handleLangCommand показывает или меняет язык чата; "-" возвращает язык
+// из конфига.
+func handleLangCommand(bot *telegramBot, req commandRequest) error {
+	available := strings.Join(supportedLangs(), ", ")
+	if len(req.Args.Raw) == 0 {
+		loc := localeFor(req.ChatID)
+		return sendTelegramMessage(bot.apiBase, req.ChatID, loc.T("lang.current", loc.T("lang.name"), available), "", nil)
+	}
+	value := req.Args.Raw[0]
+	lang, ok := normalizeLang(value)
+	if value == "-" {
+		lang, ok = "", true
+	}
+	if !ok {
+		return errors.New(localeFor(req.ChatID).T("lang.unknown", value, available))
+	}
+	if err := chatPrefs.Update(req.ChatID, func(settings *chatSettings) { settings.Lang = lang }); err != nil {
+		return err
+	}
+	loc := localeFor(req.ChatID)
+	return sendTelegramMessage(bot.apiBase, req.ChatID, loc.T("lang.set", loc.T("lang.name")), "", nil)
+}
+
+// handleTimezoneCommand показывает или меняет часовой пояс чата; "-"
+// возвращает пояс из конфига.
+func handleTimezoneCommand(bot *telegramBot, req commandRequest) error {
+	if len(req.Args.Raw) == 0 {
+		return sendTelegramMessage(bot.apiBase, req.ChatID, formatTimezoneStatus(req.Locale, "tz.current"), "", nil)
+	}
+	name := req.Args.Raw[0]
+	if name == "-" {
+		name = ""
+	} else {
+		tz, err := loadTimezone(name)
+		if err != nil {
+			return err
+		}
+		name = tz.String()
+	}
+	if err := chatPrefs.Update(req.ChatID, func(settings *chatSettings) { settings.Timezone = name }); err != nil {
+		return err
+	}
+	return sendTelegramMessage(bot.apiBase, req.ChatID, formatTimezoneStatus(localeFor(req.ChatID), "tz.set"), "", nil)
+}
+
+func formatTimezoneStatus(loc locale, key string) string {
+	return loc.T(key, loc.Location().String(), time.Now().In(loc.Location()).Format("2006-01-02 15:04"))
+}
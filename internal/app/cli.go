@@ -0,0 +1,584 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const cliFormatText = "text"
+
+type cliCommand struct {
+	Name        string
+	Usage       string
+	Description string
+	Run         func(args []string, stdout io.Writer) error
+}
+
+type cliOptions struct {
+	config   string
+	accounts string
+	format   string
+	window   string
+	output   string
+	// writer — команда дописывает локальную историю (бот, мониторинг,
+	// backfill); остальные читают её, не мешая работающему боту.
+	writer bool
+}
+
+func cliCommands() []cliCommand {
+	return []cliCommand{
+		{"report", "[флаги] [фильтры]", "последние матчи всех игроков", runReportCLI},
+		{"rating", "[флаги] [метрика] [фильтры]", "рейтинг игроков", runRatingCLI},
+		{"friends", "[флаги] [фильтры]", "лучшие напарники", runFriendsCLI},
+		{"match", "[флаги] <match_id>", "таблица матча", runMatchCLI},
+		{"export", "[флаги] matches|rating|friends [csv|json|md] [фильтры]", "выгрузка отчёта, как /export", runExportCLI},
+		{"monitor", "[флаги]", "только мониторинг новых матчей", runMonitorCLI},
+		{"bot", "[флаги]", "Telegram-бот и уведомления", runBotCLI},
+		{"backfill", "[флаги]", "загрузка полной истории матчей в локальное хранилище", runBackfillCLI},
+		{"doctor", "[флаги]", "проверка настроек и доступа к API", runDoctorCLI},
+	}
+}
+
+// Run выполняет подкоманду. Без подкоманды режим выбирается как раньше:
+// бот, если задан токен Telegram, иначе отчёт в консоль и мониторинг.
+func Run(args []string) error {
+	if len(args) == 0 {
+		return runDefault()
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "--help" {
+		printCLIUsage(os.Stdout)
+		return nil
+	}
+	for _, cmd := range cliCommands() {
+		if cmd.Name == name {
+			err := cmd.Run(args[1:], os.Stdout)
+			if errors.Is(err, flag.ErrHelp) {
+				return nil
+			}
+			return err
+		}
+	}
+	printCLIUsage(os.Stderr)
+	return fmt.Errorf("неизвестная команда: %s", name)
+}
+
+func printCLIUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: easykatka [команда] [флаги]")
+	fmt.Fprintln(w, "\nБез команды: бот, если задан токен Telegram, иначе отчёт и мониторинг.")
+	fmt.Fprintf(w, "Конфиг: %s или путь из %s.\n", defaultConfigFile, configPathEnv)
+	fmt.Fprintln(w, "\nКоманды:")
+	for _, cmd := range cliCommands() {
+		fmt.Fprintf(w, "  %-8s %s — %s\n", cmd.Name, cmd.Usage, cmd.Description)
+	}
+	fmt.Fprintln(w, "\nФлаги команды: easykatka <команда> -h")
+}
+
+func runDefault() error {
+	cfg, accountStore, err := cliOptions{config: defaultConfigPath(), writer: true}.load()
+	if err != nil {
+		return err
+	}
+	heroes, err := fetchHeroes()
+	if err != nil {
+		return err
+	}
+	if cfg.Telegram.Token != "" {
+		return startBot(cfg, accountStore, heroes)
+	}
+	report, err := buildReport(defaultLocale(), accountStore.Get(), heroes)
+	if err != nil {
+		return err
+	}
+	fmt.Print(report)
+	go watchAccountStore(accountStore, accountsWatchInterval, printRosterDiff(accountStore))
+	monitorMatches(accountStore, heroes, nil, cfg.Monitor.PollInterval)
+	return nil
+}
+
+func startBot(cfg config, accountStore *accountIDStore, heroes map[int]string) error {
+	if notify := telegramNotifier(cfg, heroes); notify != nil {
+		go monitorMatches(accountStore, heroes, notify, cfg.Monitor.PollInterval)
+	}
+	return runTelegramBot(cfg, accountStore, heroes)
+}
+
+// newCLIFlags создаёт набор флагов подкоманды; withOutput добавляет
+// -format, -window и -o.
+func newCLIFlags(name string, usage string, opts *cliOptions, withOutput bool) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.StringVar(&opts.config, "config", defaultConfigPath(), "файл конфигурации")
+	flags.StringVar(&opts.accounts, "accounts", "", "файл со списком аккаунтов вместо аккаунтов из конфига")
+	if withOutput {
+		flags.StringVar(&opts.format, "format", cliFormatText, "формат: text, csv, json, md")
+		flags.StringVar(&opts.window, "window", "", "окно выборки через запятую: 7d, 2w, 50, ranked")
+		flags.StringVar(&opts.output, "o", "", "файл для результата (по умолчанию stdout)")
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: easykatka %s %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseInterspersed разрешает флаги после позиционных аргументов:
+// "rating 7d -format csv" работает так же, как "rating -format csv 7d".
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// load читает конфиг и аккаунты и открывает локальную историю; -accounts
+// заменяет аккаунты из конфига.
+func (o cliOptions) load() (config, *accountIDStore, error) {
+	cfg, err := loadConfig(o.config)
+	if err != nil {
+		return config{}, nil, err
+	}
+	if err := applyConfig(cfg); err != nil {
+		return config{}, nil, err
+	}
+	if o.writer {
+		if err := matchHistory.Open(cfg.StorePath()); err != nil {
+			return config{}, nil, err
+		}
+	} else if err := matchHistory.OpenReadOnly(cfg.StorePath()); err != nil {
+		// История только ускоряет ответы, без неё команда идёт в OpenDota.
+		slog.Warn("local history unavailable", "dir", cfg.StorePath(), "error", err)
+	}
+	store, err := o.loadAccounts(cfg)
+	if err != nil {
+		return config{}, nil, err
+	}
+	return cfg, store, nil
+}
+
+func (o cliOptions) loadAccounts(cfg config) (*accountIDStore, error) {
+	if o.accounts != "" {
+		return loadAccountIDStore(o.accounts)
+	}
+	return loadConfigAccountStore(o.config, cfg)
+}
+
+// filterTokens объединяет -window и позиционные аргументы.
+func (o cliOptions) filterTokens(positional []string) []string {
+	var tokens []string
+	for _, token := range strings.Split(o.window, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return append(tokens, positional...)
+}
+
+func (o cliOptions) write(stdout io.Writer, content []byte) error {
+	if o.output == "" {
+		_, err := stdout.Write(content)
+		return err
+	}
+	if err := os.WriteFile(o.output, content, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", o.output, err)
+	}
+	return nil
+}
+
+// render выводит текстовый вариант или reportData в выбранном формате.
+func (o cliOptions) render(stdout io.Writer, text string, data reportData) error {
+	if o.format == cliFormatText {
+		return o.write(stdout, []byte(text))
+	}
+	format, ok := exportFormatAliases[strings.ToLower(o.format)]
+	if !ok {
+		return fmt.Errorf("неизвестный формат: %s", o.format)
+	}
+	content, err := renderReport(data, format)
+	if err != nil {
+		return err
+	}
+	return o.write(stdout, content)
+}
+
+func runReportCLI(args []string, stdout io.Writer) error {
+	var opts cliOptions
+	flags := newCLIFlags("report", "[флаги] [фильтры]", &opts, true)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+	filter, err := parseMatchFilter(opts.filterTokens(positional), statDefaultGames, statMaxGames)
+	if err != nil {
+		return err
+	}
+	_, accountStore, err := opts.load()
+	if err != nil {
+		return err
+	}
+	accountIDs := accountStore.Get()
+	heroes, err := fetchHeroes()
+	if err != nil {
+		return err
+	}
+	players := make([]playerMatches, 0, len(accountIDs))
+	var text strings.Builder
+	for i, accountID := range accountIDs {
+		player, err := loadPlayerMatches(accountID, filter)
+		if err != nil {
+			return err
+		}
+		players = append(players, player)
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		writeMatches(&text, defaultLocale(), player.Matches, heroes, player.Name, true)
+	}
+	return opts.render(stdout, text.String(), matchesReportData(defaultLocale(), players, heroes))
+}
+
+func runRatingCLI(args []string, stdout io.Writer) error {
+	var opts cliOptions
+	flags := newCLIFlags("rating", "[флаги] [метрика] [фильтры]", &opts, true)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+	ratingArgs, err := parseRatingArgs(opts.filterTokens(positional))
+	if err != nil {
+		return err
+	}
+	_, accountStore, err := opts.load()
+	if err != nil {
+		return err
+	}
+	accountIDs := accountStore.Get()
+	entries, err := loadRatingEntries(accountIDs, ratingArgs.Filter)
+	if err != nil {
+		return err
+	}
+	metric := lookupRatingMetric(ratingArgs.Sort)
+	sortRatingEntries(entries, metric)
+	loc := defaultLocale()
+	text := loc.T("rating.title", metric.Title(loc), ratingArgs.Filter.DescribeIn(loc)) + "\n" + formatRatingTable(loc, entries, metric, nil)
+	return opts.render(stdout, text, ratingReportData(defaultLocale(), entries))
+}
+
+func runFriendsCLI(args []string, stdout io.Writer) error {
+	var opts cliOptions
+	flags := newCLIFlags("friends", "[флаги] [фильтры]", &opts, true)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+	filter, err := parseMatchFilter(opts.filterTokens(positional), friendsDefaultGames, friendsMaxGames)
+	if err != nil {
+		return err
+	}
+	_, accountStore, err := opts.load()
+	if err != nil {
+		return err
+	}
+	accountIDs := accountStore.Get()
+	entries, err := loadBestFriends(accountIDs, filter)
+	if err != nil {
+		return err
+	}
+	return opts.render(stdout, formatBestFriendsTable(defaultLocale(), entries), friendsReportData(defaultLocale(), entries))
+}
+
+// runMatchCLI печатает таблицу матча; -format json выводит детали OpenDota как есть.
+func runMatchCLI(args []string, stdout io.Writer) error {
+	var opts cliOptions
+	flags := newCLIFlags("match", "[флаги] <match_id>", &opts, true)
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+	matchArgs, err := parseMatchArgs(positional)
+	if err != nil {
+		return err
+	}
+	// Конфиг читается до запросов, чтобы действовали лимит OpenDota, локальная
+	// история и язык; без аккаунтов просто не будет отметок ★.
+	var tracked []int64
+	if _, accountStore, err := opts.load(); err == nil {
+		tracked = accountStore.Get()
+	} else {
+		slog.Warn("config not loaded", "error", err)
+	}
+	details, err := fetchMatchDetails(matchArgs.MatchID)
+	if err != nil {
+		return err
+	}
+	switch opts.format {
+	case cliFormatText:
+	case exportJSON:
+		content, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal match: %w", err)
+		}
+		return opts.write(stdout, append(content, '\n'))
+	default:
+		return fmt.Errorf("для match доступны форматы text и json")
+	}
+	heroes, err := fetchHeroes()
+	if err != nil {
+		return err
+	}
+	loc := defaultLocale()
+	header := loc.T("scoreboard.text", details.MatchID, gameModeName(details.GameMode), lobbyTypeName(details.LobbyType),
+		formatDuration(details.Duration), details.RadiantScore, details.DireScore, winnerTeam(loc, details))
+	return opts.write(stdout, []byte(header+"\n\n"+formatMatchScoreboard(loc, details, tracked, heroes)))
+}
+
+func runExportCLI(args []string, stdout io.Writer) error {
+	var opts cliOptions
+	flags := newCLIFlags("export", "[флаги] matches|rating|friends [csv|json|md] [фильтры]", &opts, false)
+	flags.StringVar(&opts.output, "o", "", "файл для отчёта (по умолчанию stdout)")
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+	exportArgs, err := parseExportArgs(positional)
+	if err != nil {
+		return err
+	}
+	_, accountStore, err := opts.load()
+	if err != nil {
+		return err
+	}
+	accountIDs := accountStore.Get()
+	heroes, err := fetchHeroes()
+	if err != nil {
+		return err
+	}
+	_, content, err := buildExport(defaultLocale(), accountIDs, exportArgs, heroes)
+	if err != nil {
+		return err
+	}
+	return opts.write(stdout, content)
+}
+
+// runMonitorCLI следит за матчами без бота; если заданы токен Telegram
+// и чаты уведомлений, уведомления уходят в Telegram, иначе печатаются.
+func runMonitorCLI(args []string, _ io.Writer) error {
+	var opts cliOptions
+	flags := newCLIFlags("monitor", "[флаги]", &opts, false)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	opts.writer = true
+	cfg, accountStore, err := opts.load()
+	if err != nil {
+		return err
+	}
+	heroes, err := fetchHeroes()
+	if err != nil {
+		return err
+	}
+	go watchAccountStore(accountStore, accountsWatchInterval, printRosterDiff(accountStore))
+	monitorMatches(accountStore, heroes, telegramNotifier(cfg, heroes), cfg.Monitor.PollInterval)
+	return nil
+}
+
+func runBotCLI(args []string, _ io.Writer) error {
+	var opts cliOptions
+	flags := newCLIFlags("bot", "[флаги]", &opts, false)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	opts.writer = true
+	cfg, accountStore, err := opts.load()
+	if err != nil {
+		return err
+	}
+	if cfg.Telegram.Token == "" {
+		return fmt.Errorf("не задан telegram.token в конфиге или %s", telegramTokenEnv)
+	}
+	heroes, err := fetchHeroes()
+	if err != nil {
+		return err
+	}
+	return startBot(cfg, accountStore, heroes)
+}
+
+type doctorCheck struct {
+	Name string
+	Run  func() (string, error)
+}
+
+var errDoctorSkip = errors.New("пропущено")
+
+// doctorWarning — проблема, из-за которой работа не ломается.
+type doctorWarning string
+
+func (w doctorWarning) Error() string {
+	return string(w)
+}
+
+// runDoctorCLI проверяет конфиг, аккаунты, OpenDota, Telegram и каталог данных.
+func runDoctorCLI(args []string, stdout io.Writer) error {
+	var opts cliOptions
+	flags := newCLIFlags("doctor", "[флаги]", &opts, false)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	// Если конфиг не прочитался, остальные проверки идут со значениями по умолчанию.
+	cfg := defaultConfig()
+	var accountIDs []int64
+	checks := []doctorCheck{
+		{"конфиг", func() (string, error) {
+			loaded, err := loadConfig(opts.config)
+			if err != nil {
+				return "", err
+			}
+			cfg = loaded
+			if err := applyConfig(cfg); err != nil {
+				return "", err
+			}
+			if cfg.Path == "" {
+				return "файла нет, используются значения по умолчанию и переменные окружения", nil
+			}
+			return cfg.Path, nil
+		}},
+		{"аккаунты", func() (string, error) {
+			store, err := opts.loadAccounts(cfg)
+			if err != nil {
+				return "", err
+			}
+			accountIDs = store.Get()
+			return fmt.Sprintf("%s: %d", store.Source(), len(accountIDs)), nil
+		}},
+		{"OpenDota", func() (string, error) {
+			heroes, err := fetchHeroes()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("героев: %d, лимит %d запросов в минуту", len(heroes), cfg.OpenDota.RateLimit), nil
+		}},
+		{"профили", func() (string, error) {
+			if len(accountIDs) == 0 {
+				return "", errDoctorSkip
+			}
+			var failed []string
+			for _, id := range accountIDs {
+				if _, err := fetchPlayerProfile(id); err != nil {
+					failed = append(failed, fmt.Sprintf("%d (%s)", id, err.Error()))
+				}
+			}
+			if len(failed) > 0 {
+				return "", fmt.Errorf("не загрузились: %s", strings.Join(failed, ", "))
+			}
+			return fmt.Sprintf("загружено: %d", len(accountIDs)), nil
+		}},
+		{"портреты героев", func() (string, error) {
+			slugs, err := fetchHeroSlugs()
+			if err != nil {
+				return "", err
+			}
+			var missing []string
+			for _, slug := range slugs {
+				if _, err := loadHeroPortrait(slug); err != nil {
+					missing = append(missing, slug)
+				}
+			}
+			sort.Strings(missing)
+			if len(missing) > 0 {
+				return "", doctorWarning("нет портретов, уведомления уйдут текстом: " + strings.Join(missing, ", "))
+			}
+			return fmt.Sprintf("есть для всех %d героев", len(slugs)), nil
+		}},
+		{"Telegram", func() (string, error) {
+			if cfg.Telegram.Token == "" {
+				return "", errDoctorSkip
+			}
+			var me telegramUser
+			if err := callTelegram(fmt.Sprintf(telegramBaseURL, cfg.Telegram.Token), "getMe", map[string]any{}, &me); err != nil {
+				return "", err
+			}
+			return "@" + me.Username, nil
+		}},
+		{"чаты уведомлений", func() (string, error) {
+			chats := cfg.NotifyChats()
+			if len(chats) == 0 {
+				return "", errDoctorSkip
+			}
+			return formatIDList(chats), nil
+		}},
+		{"администраторы", func() (string, error) {
+			return fmt.Sprintf("%d", len(cfg.AdminIDs())), nil
+		}},
+		{"расписания", func() (string, error) {
+			store, err := loadScheduleStore(cfg.SchedulesPath())
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%d", len(store.List(0))), nil
+		}},
+		{"локальная история", func() (string, error) {
+			if err := matchHistory.OpenReadOnly(cfg.StorePath()); err != nil {
+				return "", err
+			}
+			stats := matchHistory.Stats()
+			return fmt.Sprintf("%s: игроков %d, строк матчей %d, матчей %d, сегментов %d, полная история у %d",
+				cfg.StorePath(), stats.Players, stats.Rows, stats.Matches, stats.Segments, stats.Complete), nil
+		}},
+		{"каталог данных", func() (string, error) {
+			dir := cfg.Paths.Data
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return "", err
+			}
+			file, err := os.CreateTemp(dir, ".doctor-*")
+			if err != nil {
+				return "", err
+			}
+			file.Close()
+			os.Remove(file.Name())
+			return dir + " доступен для записи", nil
+		}},
+	}
+	failed := 0
+	for _, check := range checks {
+		detail, err := check.Run()
+		var warning doctorWarning
+		switch {
+		case errors.Is(err, errDoctorSkip):
+			fmt.Fprintf(stdout, "[SKIP] %s\n", check.Name)
+		case errors.As(err, &warning):
+			fmt.Fprintf(stdout, "[WARN] %s: %s\n", check.Name, warning)
+		case err != nil:
+			failed++
+			fmt.Fprintf(stdout, "[FAIL] %s: %s\n", check.Name, err.Error())
+		default:
+			fmt.Fprintf(stdout, "[ OK ] %s: %s\n", check.Name, detail)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("не пройдено проверок: %d", failed)
+	}
+	return nil
+}
+
+func formatIDList(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	var opts cliOptions
+	flags := newCLIFlags("rating", "", &opts, true)
+	flags.SetOutput(io.Discard)
+	positional, err := parseInterspersed(flags, []string{"kda", "-format", "csv", "7d", "-accounts", "ids.txt"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(positional, " ") != "kda 7d" {
+		t.Fatalf("positional=%q", positional)
+	}
+	if opts.format != "csv" || opts.accounts != "ids.txt" {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+}
+
+func TestCLIOptionsFilterTokens(t *testing.T) {
+	opts := cliOptions{window: "7d, ranked"}
+	tokens := opts.filterTokens([]string{"kda"})
+	if strings.Join(tokens, " ") != "7d ranked kda" {
+		t.Fatalf("tokens=%q", tokens)
+	}
+}
+
+func TestCLIOptionsRender(t *testing.T) {
+	data := reportData{Columns: []reportColumn{{"player", "Игрок"}}, Rows: [][]any{{"Alpha"}}}
+	var out bytes.Buffer
+	if err := (cliOptions{format: cliFormatText}).render(&out, "text table\n", data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "text table\n" {
+		t.Fatalf("text output=%q", out.String())
+	}
+	out.Reset()
+	if err := (cliOptions{format: "markdown"}).render(&out, "", data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "| Игрок |") {
+		t.Fatalf("markdown output=%q", out.String())
+	}
+	if err := (cliOptions{format: "xml"}).render(&out, "", data); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	if err := Run([]string{"nope"}); err == nil {
+		t.Fatal("expected error for unknown command")
+	}
+}
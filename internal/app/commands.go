@@ -6,107 +6,255 @@ import (
 	"strings"
 )
 
-func isStatCommand(text string) bool {
-	if text == "" {
-		return false
-	}
-	if text == "/stat" {
-		return true
-	}
-	if strings.HasPrefix(text, "/stat@") {
-		return true
-	}
-	if strings.HasPrefix(text, "/stat ") {
-		return true
+type commandPermission int
+
+const (
+	permissionEveryone commandPermission = iota
+	permissionAdmin
+)
+
+type commandArgs struct {
+	Raw      []string
+	Text     string
+	Targets  []string
+	Limit    int
+	MatchID  int64
+	Filter   matchFilter
+	Schedule schedule
+	Sort     string
+	MinGames int
+	Format   string
+}
+
+type commandRequest struct {
+	ChatID int64
+	UserID int64
+	Locale locale
+	Args   commandArgs
+}
+
+type argsParser func(raw []string) (commandArgs, error)
+
+type commandHandler func(bot *telegramBot, req commandRequest) error
+
+type botCommand struct {
+	Name        string
+	Description string
+	Usage       string
+	Args        argsParser
+	Handle      commandHandler
+	Permission  commandPermission
+	Hidden      bool
+	// Schedulable — отчёт, который можно поставить в /schedule; остальные
+	// команды расписание не запускает.
+	Schedulable bool
+}
+
+type commandRegistry struct {
+	commands map[string]botCommand
+	order    []string
+}
+
+func newCommandRegistry(commands ...botCommand) *commandRegistry {
+	registry := &commandRegistry{commands: make(map[string]botCommand, len(commands))}
+	for _, cmd := range commands {
+		registry.Register(cmd)
 	}
-	return false
+	return registry
 }
 
-func isRatingCommand(text string) bool {
-	if text == "" {
-		return false
+func (r *commandRegistry) Register(cmd botCommand) {
+	name := strings.ToLower(cmd.Name)
+	if _, exists := r.commands[name]; !exists {
+		r.order = append(r.order, name)
 	}
-	if text == "/rating" {
-		return true
+	if cmd.Args == nil {
+		cmd.Args = parseNoArgs
 	}
-	if strings.HasPrefix(text, "/rating@") {
-		return true
+	cmd.Name = name
+	r.commands[name] = cmd
+}
+
+func (r *commandRegistry) Lookup(name string) (botCommand, bool) {
+	cmd, ok := r.commands[strings.ToLower(name)]
+	return cmd, ok
+}
+
+func (r *commandRegistry) Visible() []botCommand {
+	result := make([]botCommand, 0, len(r.order))
+	for _, name := range r.order {
+		cmd := r.commands[name]
+		if cmd.Hidden {
+			continue
+		}
+		result = append(result, cmd)
 	}
-	if strings.HasPrefix(text, "/rating ") {
-		return true
+	return result
+}
+
+func (r *commandRegistry) Help(loc locale, isAdmin bool) string {
+	var builder strings.Builder
+	builder.WriteString("<b>" + loc.T("help.title") + "</b>\n")
+	for _, cmd := range r.Visible() {
+		if cmd.Permission == permissionAdmin && !isAdmin {
+			continue
+		}
+		usage := "/" + cmd.Name
+		if args := loc.CommandUsage(cmd); args != "" {
+			usage += " " + args
+		}
+		builder.WriteString(fmt.Sprintf("%s — %s\n", escapeHTML(usage), escapeHTML(loc.CommandDescription(cmd))))
 	}
-	return false
+	return builder.String()
 }
 
-func parseFriendsCommand(text string) (bool, int, error) {
-	if text == "" {
-		return false, 0, nil
+// telegramCommandList возвращает команды в формате setMyCommands.
+func (r *commandRegistry) telegramCommandList(loc locale) []map[string]string {
+	visible := r.Visible()
+	result := make([]map[string]string, 0, len(visible))
+	for _, cmd := range visible {
+		if cmd.Permission == permissionAdmin {
+			continue
+		}
+		result = append(result, map[string]string{
+			"command":     cmd.Name,
+			"description": loc.CommandDescription(cmd),
+		})
 	}
+	return result
+}
+
+// parseCommand разбирает "/cmd", "/cmd@bot" и "/cmd args". Команды,
+// адресованные другому боту, пропускаются.
+func parseCommand(text string, botUsername string) (string, []string, bool) {
 	fields := strings.Fields(text)
-	if len(fields) == 0 {
-		return false, 0, nil
-	}
-	cmd := strings.ToLower(fields[0])
-	if strings.Contains(cmd, "@") {
-		cmd = strings.SplitN(cmd, "@", 2)[0]
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, false
 	}
-	if cmd != "/friends" {
-		return false, 0, nil
-	}
-	limit := 20
-	if len(fields) > 1 {
-		value, err := strconv.Atoi(fields[1])
-		if err != nil || value <= 0 {
-			return true, 0, fmt.Errorf("используй /friends <число>")
+	name := strings.ToLower(strings.TrimPrefix(fields[0], "/"))
+	if at := strings.Index(name, "@"); at >= 0 {
+		target := name[at+1:]
+		name = name[:at]
+		if botUsername != "" && !strings.EqualFold(target, botUsername) {
+			return "", nil, false
 		}
-		limit = value
 	}
-	return true, limit, nil
+	if name == "" {
+		return "", nil, false
+	}
+	return name, fields[1:], true
 }
 
-func isChatIDCommand(text string) bool {
-	if text == "" {
-		return false
-	}
-	if text == "/chatid" {
-		return true
-	}
-	if strings.HasPrefix(text, "/chatid@") {
-		return true
+func parseNoArgs(raw []string) (commandArgs, error) {
+	return commandArgs{Raw: raw}, nil
+}
+
+var (
+	parseStatArgs    = filterArgs(statDefaultGames, statMaxGames)
+	parseFriendsArgs = filterArgs(friendsDefaultGames, friendsMaxGames)
+	parseChartArgs   = playerLimitArgs("usage.chart", chartDefaultGames, 2, chartMaxGames)
+	parseHeroesArgs  = playerLimitArgs("usage.heroes", heroesDefaultTop, 1, heroesMaxTop)
+	parseRolesArgs   = playerLimitArgs("usage.roles", rolesDefaultGames, 1, rolesMaxGames)
+	parseHeroArgs    = textArgs("usage.hero")
+	parseLastArgs    = textArgs("usage.last")
+)
+
+// parseAwardsArgs по умолчанию берёт матчи за последнюю неделю.
+func parseAwardsArgs(raw []string) (commandArgs, error) {
+	filter, err := parseMatchFilter(raw, 0, awardsMaxGames)
+	if err != nil {
+		return commandArgs{}, err
 	}
-	if strings.HasPrefix(text, "/chatid ") {
-		return true
+	if filter.Days == 0 && filter.Limit == 0 {
+		filter.Days = awardsDefaultDays
 	}
-	return false
+	return commandArgs{Raw: raw, Limit: filter.Limit, Filter: filter}, nil
 }
 
-func isTestCommand(text string) bool {
-	if text == "" {
-		return false
+func parseIDArgs(raw []string) (commandArgs, error) {
+	if len(raw) != 1 {
+		return commandArgs{}, newLocalizedError("err.number_required")
 	}
-	if text == "/test" {
-		return true
+	value, err := strconv.Atoi(strings.TrimPrefix(raw[0], "#"))
+	if err != nil || value <= 0 {
+		return commandArgs{}, newLocalizedError("err.bad_number", raw[0])
 	}
-	if strings.HasPrefix(text, "/test@") {
-		return true
+	return commandArgs{Raw: raw, Limit: value}, nil
+}
+
+// playerLimitArgs разбирает "<текст> [число]": число в конце необязательно и
+// учитывается только после текста, чтобы "/chart 123" искал игрока 123.
+func playerLimitArgs(usageKey string, defaultLimit int, minLimit int, maxLimit int) argsParser {
+	return func(raw []string) (commandArgs, error) {
+		args := commandArgs{Raw: raw, Limit: defaultLimit}
+		words := raw
+		if len(words) > 1 {
+			if value, err := strconv.Atoi(words[len(words)-1]); err == nil {
+				if value < minLimit || value > maxLimit {
+					return commandArgs{}, newLocalizedError("err.limit_range", minLimit, maxLimit)
+				}
+				args.Limit = value
+				words = words[:len(words)-1]
+			}
+		}
+		args.Text = strings.Join(words, " ")
+		if args.Text == "" {
+			return commandArgs{}, newLocalizedError(usageKey)
+		}
+		return args, nil
 	}
-	if strings.HasPrefix(text, "/test ") {
-		return true
+}
+
+func textArgs(usageKey string) argsParser {
+	return func(raw []string) (commandArgs, error) {
+		text := strings.Join(raw, " ")
+		if text == "" {
+			return commandArgs{}, newLocalizedError(usageKey)
+		}
+		return commandArgs{Raw: raw, Text: text}, nil
 	}
-	return false
 }
-func isReloadAccsCommand(text string) bool {
-	if text == "" {
-		return false
+
+// parseCompareArgs разбирает "/compare <A> <B> [число игр]". Имена с
+// пробелами разделяются словом "vs" или символом "|".
+func parseCompareArgs(raw []string) (commandArgs, error) {
+	usage := newLocalizedError("usage.compare")
+	args := commandArgs{Raw: raw, Limit: compareDefaultGames}
+	words := raw
+	if len(words) > 2 {
+		if value, err := strconv.Atoi(words[len(words)-1]); err == nil {
+			if value <= 0 || value > compareMaxGames {
+				return commandArgs{}, newLocalizedError("err.games_range", compareMaxGames)
+			}
+			args.Limit = value
+			words = words[:len(words)-1]
+		}
+	}
+	for i, word := range words {
+		if strings.EqualFold(word, "vs") || word == "|" {
+			left := strings.Join(words[:i], " ")
+			right := strings.Join(words[i+1:], " ")
+			if left == "" || right == "" {
+				return commandArgs{}, usage
+			}
+			args.Targets = []string{left, right}
+			return args, nil
+		}
 	}
-	if text == "/reload" {
-		return true
+	if len(words) != 2 {
+		return commandArgs{}, usage
 	}
-	if strings.HasPrefix(text, "/reload@") {
-		return true
+	args.Targets = []string{words[0], words[1]}
+	return args, nil
+}
+
+func parseMatchArgs(raw []string) (commandArgs, error) {
+	if len(raw) != 1 {
+		return commandArgs{}, newLocalizedError("usage.match")
 	}
-	if strings.HasPrefix(text, "/reload ") {
-		return true
+	matchID, err := strconv.ParseInt(raw[0], 10, 64)
+	if err != nil || matchID <= 0 {
+		return commandArgs{}, newLocalizedError("err.bad_match_id", raw[0])
 	}
-	return false
+	return commandArgs{Raw: raw, MatchID: matchID}, nil
 }
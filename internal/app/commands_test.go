@@ -1,111 +1,158 @@
 package app
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
-func TestIsStatCommand(t *testing.T) {
-	// Проверяем допустимые формы команды /stat.
-	cases := []string{"/stat", "/stat@bot", "/stat 123"}
+func TestParseCommand_Forms(t *testing.T) {
+	// Проверяем допустимые формы команды: /cmd, /cmd@bot, /cmd args.
+	cases := []string{"/stat", "/stat@bot", "/stat@Bot", "/stat 123"}
 	for _, input := range cases {
-		if !isStatCommand(input) {
-			t.Fatalf("expected true for %q", input)
+		name, _, ok := parseCommand(input, "bot")
+		if !ok || name != "stat" {
+			t.Fatalf("parseCommand(%q) = %q, %v; want stat, true", input, name, ok)
 		}
 	}
-	// Проверяем, что пустая строка не считается командой.
-	if isStatCommand("") {
-		t.Fatal("expected false for empty input")
+	// Пустая строка и обычный текст не считаются командами.
+	for _, input := range []string{"", "stat", "/", "/@bot"} {
+		if _, _, ok := parseCommand(input, "bot"); ok {
+			t.Fatalf("expected false for %q", input)
+		}
 	}
 }
 
-func TestIsRatingCommand(t *testing.T) {
-	// Проверяем допустимые формы команды /rating.
-	cases := []string{"/rating", "/rating@bot", "/rating 50"}
-	for _, input := range cases {
-		if !isRatingCommand(input) {
-			t.Fatalf("expected true for %q", input)
-		}
+func TestParseCommand_OtherBot(t *testing.T) {
+	// Команда для другого бота должна игнорироваться.
+	if _, _, ok := parseCommand("/stat@other_bot", "easykatka_bot"); ok {
+		t.Fatal("expected false for command addressed to another bot")
+	}
+}
+
+func TestParseCommand_Args(t *testing.T) {
+	name, args, ok := parseCommand("/Friends@bot 50 extra", "bot")
+	if !ok || name != "friends" {
+		t.Fatalf("unexpected parse result: %q %v", name, ok)
 	}
-	// Проверяем, что пустая строка не считается командой.
-	if isRatingCommand("") {
-		t.Fatal("expected false for empty input")
+	if len(args) != 2 || args[0] != "50" || args[1] != "extra" {
+		t.Fatalf("unexpected args: %#v", args)
 	}
 }
 
-func TestParseFriendsCommand_Default(t *testing.T) {
+func TestParseFriendsArgs_Default(t *testing.T) {
 	// Без аргументов лимит должен быть 20.
-	ok, limit, err := parseFriendsCommand("/friends")
-	if !ok || err != nil {
-		t.Fatalf("expected ok without error, got ok=%v err=%v", ok, err)
+	args, err := parseFriendsArgs(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-	if limit != 20 {
-		t.Fatalf("limit=%d, want 20", limit)
+	if args.Limit != 20 {
+		t.Fatalf("limit=%d, want 20", args.Limit)
 	}
 }
 
-func TestParseFriendsCommand_WithLimit(t *testing.T) {
+func TestParseFriendsArgs_WithLimit(t *testing.T) {
 	// С числом лимит должен парситься.
-	ok, limit, err := parseFriendsCommand("/friends 50")
-	if !ok || err != nil {
-		t.Fatalf("expected ok without error, got ok=%v err=%v", ok, err)
+	args, err := parseFriendsArgs([]string{"50"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
-	if limit != 50 {
-		t.Fatalf("limit=%d, want 50", limit)
+	if args.Limit != 50 {
+		t.Fatalf("limit=%d, want 50", args.Limit)
 	}
 }
 
-func TestParseFriendsCommand_Invalid(t *testing.T) {
+func TestParseFriendsArgs_Invalid(t *testing.T) {
 	// Некорректный аргумент должен давать ошибку.
-	ok, _, err := parseFriendsCommand("/friends abc")
-	if !ok {
-		t.Fatal("expected ok=true for /friends command")
-	}
-	if err == nil {
+	if _, err := parseFriendsArgs([]string{"abc"}); err == nil {
 		t.Fatal("expected error for invalid limit")
 	}
 }
 
-func TestParseFriendsCommand_NotCommand(t *testing.T) {
-	// Для других команд parseFriendsCommand должен вернуть ok=false.
-	ok, _, err := parseFriendsCommand("/stat")
-	if ok || err != nil {
-		t.Fatalf("expected ok=false without error, got ok=%v err=%v", ok, err)
+func TestDefaultCommands_Registered(t *testing.T) {
+	registry := defaultCommands()
+	for _, name := range []string{"stat", "rating", "friends", "chatid", "test", "reload", "help"} {
+		if _, ok := registry.Lookup(name); !ok {
+			t.Fatalf("command %q is not registered", name)
+		}
 	}
 }
 
-func TestIsChatIDCommand(t *testing.T) {
-	// Проверяем допустимые формы команды /chatid.
-	cases := []string{"/chatid", "/chatid@bot", "/chatid 1"}
-	for _, input := range cases {
-		if !isChatIDCommand(input) {
-			t.Fatalf("expected true for %q", input)
-		}
+func TestCommandRegistry_Help(t *testing.T) {
+	registry := defaultCommands()
+	// Скрытые и админские команды не должны показываться обычным пользователям.
+	help := registry.Help(newLocale(langRU), false)
+	if !strings.Contains(help, "/friends [период] [режим] [число игр]") {
+		t.Fatalf("help missing usage: %q", help)
+	}
+	if strings.Contains(help, "/test") || strings.Contains(help, "/reload") {
+		t.Fatalf("help contains hidden or admin commands: %q", help)
 	}
-	// Проверяем, что пустая строка не считается командой.
-	if isChatIDCommand("") {
-		t.Fatal("expected false for empty input")
+	if !strings.Contains(registry.Help(newLocale(langRU), true), "/reload") {
+		t.Fatal("admin help should list /reload")
 	}
 }
 
-func TestIsTestCommand(t *testing.T) {
-	// Проверяем допустимые формы команды /test.
-	cases := []string{"/test", "/test@bot", "/test now"}
-	for _, input := range cases {
-		if !isTestCommand(input) {
-			t.Fatalf("expected true for %q", input)
+func TestCommandRegistry_TelegramCommandList(t *testing.T) {
+	list := defaultCommands().telegramCommandList(newLocale(langRU))
+	for _, entry := range list {
+		if entry["command"] == "test" || entry["command"] == "reload" {
+			t.Fatalf("unexpected command in setMyCommands list: %v", entry)
 		}
+		if entry["description"] == "" {
+			t.Fatalf("missing description for %v", entry)
+		}
+	}
+}
+
+func TestParseChartArgs(t *testing.T) {
+	args, err := parseChartArgs([]string{"Some", "Nick", "30"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Text != "Some Nick" || args.Limit != 30 {
+		t.Fatalf("unexpected args: %+v", args)
+	}
+	// Одно число без имени трактуется как игрок (account_id).
+	args, err = parseChartArgs([]string{"123"})
+	if err != nil || args.Text != "123" || args.Limit != chartDefaultGames {
+		t.Fatalf("unexpected args: %+v, %v", args, err)
 	}
-	if isTestCommand("") {
-		t.Fatal("expected false for empty input")
+	if _, err := parseChartArgs(nil); err == nil {
+		t.Fatal("expected error without player")
+	}
+	if _, err := parseChartArgs([]string{"Nick", "1000"}); err == nil {
+		t.Fatal("expected error for too many games")
 	}
 }
 
-func TestIsReloadAccsCommand(t *testing.T) {
-	cases := []string{"/reload", "/reload@bot", "/reload now"}
-	for _, input := range cases {
-		if !isReloadAccsCommand(input) {
-			t.Fatalf("expected true for %q", input)
+func TestParseCompareArgs(t *testing.T) {
+	args, err := parseCompareArgs([]string{"Alpha", "Beta", "100"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(args.Targets) != 2 || args.Targets[0] != "Alpha" || args.Targets[1] != "Beta" || args.Limit != 100 {
+		t.Fatalf("unexpected args: %+v", args)
+	}
+	// Имена с пробелами через "vs".
+	args, err = parseCompareArgs([]string{"Big", "Alpha", "vs", "Beta", "Two"})
+	if err != nil || args.Targets[0] != "Big Alpha" || args.Targets[1] != "Beta Two" || args.Limit != compareDefaultGames {
+		t.Fatalf("unexpected args: %+v, %v", args, err)
+	}
+	for _, raw := range [][]string{nil, {"Alpha"}, {"A", "B", "C"}, {"vs", "B"}} {
+		if _, err := parseCompareArgs(raw); err == nil {
+			t.Fatalf("expected error for %v", raw)
 		}
 	}
-	if isReloadAccsCommand("") {
-		t.Fatal("expected false for empty input")
+}
+
+func TestParseMatchArgs(t *testing.T) {
+	args, err := parseMatchArgs([]string{"7890123456"})
+	if err != nil || args.MatchID != 7890123456 {
+		t.Fatalf("unexpected result: %+v, %v", args, err)
+	}
+	for _, raw := range [][]string{nil, {"abc"}, {"-1"}, {"1", "2"}} {
+		if _, err := parseMatchArgs(raw); err == nil {
+			t.Fatalf("expected error for %v", raw)
+		}
 	}
 }
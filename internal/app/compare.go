@@ -0,0 +1,230 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type heroCount struct {
+	HeroID int
+	Games  int
+}
+
+type playerSummary struct {
+	Name      string
+	Games     int
+	Wins      int
+	Kills     float64
+	Deaths    float64
+	Assists   float64
+	GPM       float64
+	XPM       float64
+	TopHeroes []heroCount
+}
+
+type duoSummary struct {
+	Together     int
+	TogetherWins int
+	Against      int
+	AgainstWinsA int
+}
+
+func summarizeMatches(name string, matches []recentMatch) playerSummary {
+	summary := playerSummary{Name: name, Games: len(matches)}
+	if len(matches) == 0 {
+		return summary
+	}
+	heroGames := make(map[int]int)
+	for _, m := range matches {
+		if matchWin(m) {
+			summary.Wins++
+		}
+		summary.Kills += float64(m.Kills)
+		summary.Deaths += float64(m.Deaths)
+		summary.Assists += float64(m.Assists)
+		summary.GPM += float64(m.GPM)
+		summary.XPM += float64(m.XPM)
+		heroGames[m.HeroID]++
+	}
+	n := float64(len(matches))
+	summary.Kills /= n
+	summary.Deaths /= n
+	summary.Assists /= n
+	summary.GPM /= n
+	summary.XPM /= n
+	for heroID, games := range heroGames {
+		summary.TopHeroes = append(summary.TopHeroes, heroCount{HeroID: heroID, Games: games})
+	}
+	sort.Slice(summary.TopHeroes, func(i, j int) bool {
+		if summary.TopHeroes[i].Games == summary.TopHeroes[j].Games {
+			return summary.TopHeroes[i].HeroID < summary.TopHeroes[j].HeroID
+		}
+		return summary.TopHeroes[i].Games > summary.TopHeroes[j].Games
+	})
+	if len(summary.TopHeroes) > 3 {
+		summary.TopHeroes = summary.TopHeroes[:3]
+	}
+	return summary
+}
+
+// summarizeDuo сопоставляет общие матчи с обеих сторон по match_id, чтобы
+// понять, играли игроки в одной команде или друг против друга.
+func summarizeDuo(aMatches []playerMatch, bMatches []playerMatch) duoSummary {
+	bSlots := make(map[int64]int, len(bMatches))
+	for _, m := range bMatches {
+		bSlots[m.MatchID] = m.PlayerSlot
+	}
+	var duo duoSummary
+	for _, m := range aMatches {
+		bSlot, ok := bSlots[m.MatchID]
+		if !ok {
+			continue
+		}
+		aWin := isWin(m.RadiantWin, m.PlayerSlot)
+		if (m.PlayerSlot < 128) == (bSlot < 128) {
+			duo.Together++
+			if aWin {
+				duo.TogetherWins++
+			}
+			continue
+		}
+		duo.Against++
+		if aWin {
+			duo.AgainstWinsA++
+		}
+	}
+	return duo
+}
+
+// buildCompareTable: строки каждого игрока — по его последним limit
+// матчам. Общие игры и игры без другого запрашиваются у OpenDota через
+// included_account_id и excluded_account_id за один период для обоих —
+// от самого старого матча в двух окнах, — поэтому стороны сравнимы, а
+// общая история не теряется, если окна игроков почти не пересекаются.
+func buildCompareTable(loc locale, a trackedPlayer, b trackedPlayer, limit int, heroes map[int]string) (string, error) {
+	aMatches, err := fetchPlayerMatchStats(a.AccountID, limit)
+	if err != nil {
+		return "", err
+	}
+	bMatches, err := fetchPlayerMatchStats(b.AccountID, limit)
+	if err != nil {
+		return "", err
+	}
+	days := compareWindowDays(aMatches, bMatches, time.Now())
+	filter := matchFilter{Days: days}
+	aWith, err := fetchMatchesWith(a.AccountID, b.AccountID, filter)
+	if err != nil {
+		return "", err
+	}
+	bWith, err := fetchMatchesWith(b.AccountID, a.AccountID, filter)
+	if err != nil {
+		return "", err
+	}
+	aWithout, err := fetchMatchesWithout(a.AccountID, b.AccountID, filter)
+	if err != nil {
+		return "", err
+	}
+	bWithout, err := fetchMatchesWithout(b.AccountID, a.AccountID, filter)
+	if err != nil {
+		return "", err
+	}
+	aWithoutWR, _ := calcWinrateFromMatches(aWithout)
+	bWithoutWR, _ := calcWinrateFromMatches(bWithout)
+	return formatCompareTable(
+		loc,
+		summarizeMatches(a.Name, aMatches),
+		summarizeMatches(b.Name, bMatches),
+		summarizeDuo(aWith, bWith),
+		[2]float64{aWithoutWR, bWithoutWR},
+		days,
+		heroes,
+	), nil
+}
+
+// compareWindowDays — период в днях (параметр date OpenDota), который
+// покрывает окна обоих игроков; 0 — вся история.
+func compareWindowDays(aMatches []recentMatch, bMatches []recentMatch, now time.Time) int {
+	var oldest int64
+	for _, matches := range [][]recentMatch{aMatches, bMatches} {
+		for _, m := range matches {
+			if oldest == 0 || m.StartTime < oldest {
+				oldest = m.StartTime
+			}
+		}
+	}
+	if oldest == 0 {
+		return 0
+	}
+	return int(now.Sub(time.Unix(oldest, 0)).Hours()/24) + 1
+}
+
+// formatCompareTable: days — период, за который посчитаны общие игры и
+// винрейт без другого, 0 — вся история.
+func formatCompareTable(loc locale, a playerSummary, b playerSummary, duo duoSummary, withoutWinrate [2]float64, days int, heroes map[int]string) string {
+	var builder strings.Builder
+	row := func(label string, left string, right string) {
+		builder.WriteString(fmt.Sprintf("%-14s  %-16s  %-16s\n", label, trimTo(left, 16), trimTo(right, 16)))
+	}
+	winrate := func(s playerSummary) string {
+		if s.Games == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f%%", float64(s.Wins)*100/float64(s.Games))
+	}
+	hero := func(s playerSummary, i int) string {
+		if i >= len(s.TopHeroes) {
+			return ""
+		}
+		name := heroes[s.TopHeroes[i].HeroID]
+		if name == "" {
+			name = fmt.Sprintf("Hero #%d", s.TopHeroes[i].HeroID)
+		}
+		return fmt.Sprintf("%s (%d)", trimTo(name, 11), s.TopHeroes[i].Games)
+	}
+
+	row("", a.Name, b.Name)
+	row(loc.T("compare.games"), strconv.Itoa(a.Games), strconv.Itoa(b.Games))
+	row("Winrate", winrate(a), winrate(b))
+	row(loc.T("compare.kda"),
+		fmt.Sprintf("%.1f/%.1f/%.1f", a.Kills, a.Deaths, a.Assists),
+		fmt.Sprintf("%.1f/%.1f/%.1f", b.Kills, b.Deaths, b.Assists))
+	row("GPM/XPM",
+		fmt.Sprintf("%.0f/%.0f", a.GPM, a.XPM),
+		fmt.Sprintf("%.0f/%.0f", b.GPM, b.XPM))
+	row(loc.T("compare.without"),
+		fmt.Sprintf("%.1f%%", withoutWinrate[0]),
+		fmt.Sprintf("%.1f%%", withoutWinrate[1]))
+	for i := 0; i < 3; i++ {
+		label := ""
+		if i == 0 {
+			label = loc.T("compare.top")
+		}
+		left, right := hero(a, i), hero(b, i)
+		if left == "" && right == "" {
+			break
+		}
+		row(label, left, right)
+	}
+
+	builder.WriteString("\n")
+	if days > 0 {
+		builder.WriteString(loc.T("compare.period", loc.N(days, "days")) + "\n")
+	} else {
+		builder.WriteString(loc.T("compare.period.all") + "\n")
+	}
+	if duo.Together > 0 {
+		builder.WriteString(loc.T("compare.together", loc.N(duo.Together, "games"), float64(duo.TogetherWins)*100/float64(duo.Together)) + "\n")
+	} else {
+		builder.WriteString(loc.T("compare.together.none") + "\n")
+	}
+	if duo.Against > 0 {
+		builder.WriteString(loc.T("compare.against", loc.N(duo.Against, "games"),
+			trimTo(a.Name, 16), duo.AgainstWinsA, duo.Against-duo.AgainstWinsA, trimTo(b.Name, 16)) + "\n")
+	} else {
+		builder.WriteString(loc.T("compare.against.none") + "\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummarizeMatches(t *testing.T) {
+	matches := []recentMatch{
+		{HeroID: 1, Kills: 4, Deaths: 2, Assists: 6, GPM: 400, XPM: 500, RadiantWin: true},
+		{HeroID: 1, Kills: 2, Deaths: 4, Assists: 2, GPM: 600, XPM: 700, RadiantWin: false},
+		{HeroID: 2, Kills: 0, Deaths: 0, Assists: 1, GPM: 500, XPM: 600, RadiantWin: true},
+	}
+	s := summarizeMatches("P", matches)
+	if s.Games != 3 || s.Wins != 2 {
+		t.Fatalf("unexpected games/wins: %+v", s)
+	}
+	if s.Kills != 2 || s.Deaths != 2 || s.Assists != 3 || s.GPM != 500 || s.XPM != 600 {
+		t.Fatalf("unexpected averages: %+v", s)
+	}
+	if len(s.TopHeroes) != 2 || s.TopHeroes[0].HeroID != 1 || s.TopHeroes[0].Games != 2 {
+		t.Fatalf("unexpected top heroes: %+v", s.TopHeroes)
+	}
+}
+
+func TestSummarizeDuo(t *testing.T) {
+	// Матч 1 — в одной команде (победа), матч 2 — друг против друга (A проиграл).
+	a := []playerMatch{
+		{MatchID: 1, PlayerSlot: 0, RadiantWin: true},
+		{MatchID: 2, PlayerSlot: 1, RadiantWin: false},
+	}
+	b := []playerMatch{
+		{MatchID: 1, PlayerSlot: 2, RadiantWin: true},
+		{MatchID: 2, PlayerSlot: 128, RadiantWin: false},
+	}
+	duo := summarizeDuo(a, b)
+	if duo.Together != 1 || duo.TogetherWins != 1 || duo.Against != 1 || duo.AgainstWinsA != 0 {
+		t.Fatalf("unexpected duo summary: %+v", duo)
+	}
+}
+
+func TestCompareWindowDays(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	day := int64(24 * 60 * 60)
+	// Окно B уходит дальше в прошлое: общий период берётся по нему, чтобы
+	// совместные игры A считались за то же время.
+	a := []recentMatch{{StartTime: now.Unix() - day}, {StartTime: now.Unix() - 2*day}}
+	b := []recentMatch{{StartTime: now.Unix() - 3*day}, {StartTime: now.Unix() - 30*day - 3600}}
+	if got := compareWindowDays(a, b, now); got != 31 {
+		t.Fatalf("days=%d", got)
+	}
+	if got := compareWindowDays(nil, nil, now); got != 0 {
+		t.Fatalf("empty days=%d", got)
+	}
+}
+
+func TestFormatCompareTable(t *testing.T) {
+	a := playerSummary{Name: "Alpha", Games: 2, Wins: 1, TopHeroes: []heroCount{{HeroID: 2, Games: 2}}}
+	b := playerSummary{Name: "Beta", Games: 4, Wins: 3}
+	out := formatCompareTable(newLocale(langRU), a, b, duoSummary{Together: 2, TogetherWins: 1}, [2]float64{50, 75}, 14, map[int]string{2: "Axe"})
+	for _, want := range []string{"Alpha", "Beta", "50.0%", "75.0%", "Axe (2)", "Вместе: 2 игры", "Друг против друга: нет игр", "за 14 дней"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q: %q", want, out)
+		}
+	}
+	en := formatCompareTable(newLocale(langEN), a, b, duoSummary{Together: 1, TogetherWins: 1}, [2]float64{}, 0, nil)
+	if !strings.Contains(en, "Together: 1 game,") || !strings.Contains(en, "Head to head: no games") || !strings.Contains(en, "all time") {
+		t.Fatalf("en=%q", en)
+	}
+}
@@ -0,0 +1,504 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type config struct {
+	Path     string
+	Telegram telegramConfig
+	OpenDota opendotaConfig
+	Monitor  monitorConfig
+	Paths    pathsConfig
+	Log      logConfig
+	Locale   localeConfig
+	Accounts []accountConfig
+	Chats    []chatConfig
+}
+
+type telegramConfig struct {
+	Token string
+}
+
+type opendotaConfig struct {
+	RateLimit int
+}
+
+type monitorConfig struct {
+	PollInterval time.Duration
+}
+
+type pathsConfig struct {
+	Accounts string
+	Data     string
+}
+
+// localeConfig: Timezone — IANA-имя вроде "Europe/Moscow"; пустое значение
+// означает часовой пояс системы (переменная TZ).
+type localeConfig struct {
+	Lang     string
+	Timezone string
+}
+
+type accountConfig struct {
+	ID     int64
+	Alias  string
+	Notify bool
+}
+
+type chatConfig struct {
+	ID     int64
+	Notify bool
+	Admin  bool
+}
+
+func defaultConfig() config {
+	return config{
+		OpenDota: opendotaConfig{RateLimit: opendotaRateCap},
+		Monitor:  monitorConfig{PollInterval: defaultPollInterval},
+		Paths:    pathsConfig{Accounts: defaultAccountsPath, Data: defaultDataDir},
+		Log:      logConfig{Level: "info", Format: logFormatText},
+		Locale:   localeConfig{Lang: langRU},
+	}
+}
+
+// defaultConfigPath — EASYKATKA_CONFIG или config.toml в рабочем каталоге.
+func defaultConfigPath() string {
+	if path := strings.TrimSpace(os.Getenv(configPathEnv)); path != "" {
+		return path
+	}
+	return defaultConfigFile
+}
+
+// loadConfig читает конфиг, накладывает переменные окружения и проверяет
+// результат. Если файла по умолчанию нет, используются значения по
+// умолчанию: так продолжают работать установки только с .env и account_id.
+func loadConfig(path string) (config, error) {
+	cfg := defaultConfig()
+	raw, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && path == defaultConfigFile:
+	case err != nil:
+		return config{}, fmt.Errorf("read config: %w", err)
+	default:
+		cfg.Path = path
+		doc, err := parseTOML(path, string(raw))
+		if err != nil {
+			return config{}, err
+		}
+		if err := decodeConfig(doc, &cfg); err != nil {
+			return config{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return config{}, err
+	}
+	if err := cfg.validate(); err != nil {
+		return config{}, fmt.Errorf("некорректный конфиг:\n%w", err)
+	}
+	return cfg, nil
+}
+
+type tomlField func(value any) error
+
+// decodeTOMLTable раскладывает значения таблицы по полям; неизвестные
+// ключи — ошибка, чтобы опечатки не терялись молча.
+func decodeTOMLTable(name string, table map[string]any, fields map[string]tomlField) error {
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("неизвестный ключ %s.%s", name, key)
+		}
+		if err := field(table[key]); err != nil {
+			return fmt.Errorf("%s.%s: %w", name, key, err)
+		}
+	}
+	return nil
+}
+
+func tomlString(dst *string) tomlField {
+	return func(value any) error {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("ожидалась строка")
+		}
+		*dst = s
+		return nil
+	}
+}
+
+func tomlInt(dst *int) tomlField {
+	return func(value any) error {
+		n, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("ожидалось целое число")
+		}
+		*dst = int(n)
+		return nil
+	}
+}
+
+// tomlID принимает account_id, SteamID64 или chat_id числом либо строкой.
+func tomlID(dst *int64) tomlField {
+	return func(value any) error {
+		switch v := value.(type) {
+		case int64:
+			*dst = v
+		case string:
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return fmt.Errorf("ожидалось число: %s", v)
+			}
+			*dst = n
+		default:
+			return fmt.Errorf("ожидалось число")
+		}
+		return nil
+	}
+}
+
+// tomlAccountID принимает число или строку в любом формате parseSteamID.
+func tomlAccountID(dst *int64) tomlField {
+	return func(value any) error {
+		raw := value
+		if n, ok := value.(int64); ok {
+			raw = strconv.FormatInt(n, 10)
+		}
+		s, ok := raw.(string)
+		if !ok {
+			return fmt.Errorf("ожидался account_id, SteamID или ссылка на профиль")
+		}
+		id, err := parseSteamID(s)
+		if err != nil {
+			return err
+		}
+		*dst = id
+		return nil
+	}
+}
+
+func tomlBool(dst *bool) tomlField {
+	return func(value any) error {
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("ожидалось true или false")
+		}
+		*dst = b
+		return nil
+	}
+}
+
+func tomlDuration(dst *time.Duration) tomlField {
+	return func(value any) error {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf(`ожидалась длительность строкой, например "5m"`)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("некорректная длительность %q", s)
+		}
+		*dst = d
+		return nil
+	}
+}
+
+func decodeConfig(doc map[string]any, cfg *config) error {
+	tables := map[string]map[string]tomlField{
+		"telegram": {"token": tomlString(&cfg.Telegram.Token)},
+		"opendota": {"rate_limit": tomlInt(&cfg.OpenDota.RateLimit)},
+		"monitor":  {"poll_interval": tomlDuration(&cfg.Monitor.PollInterval)},
+		"paths": {
+			"accounts": tomlString(&cfg.Paths.Accounts),
+			"data":     tomlString(&cfg.Paths.Data),
+		},
+		"log": {
+			"level":  tomlString(&cfg.Log.Level),
+			"format": tomlString(&cfg.Log.Format),
+		},
+		"locale": {
+			"lang":     tomlString(&cfg.Locale.Lang),
+			"timezone": tomlString(&cfg.Locale.Timezone),
+		},
+	}
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := doc[key]
+		switch key {
+		case "accounts":
+			list, ok := value.([]map[string]any)
+			if !ok {
+				return fmt.Errorf("accounts должен быть массивом таблиц [[accounts]]")
+			}
+			for i, table := range list {
+				account := accountConfig{Notify: true}
+				err := decodeTOMLTable(fmt.Sprintf("accounts[%d]", i), table, map[string]tomlField{
+					"id":     tomlAccountID(&account.ID),
+					"alias":  tomlString(&account.Alias),
+					"notify": tomlBool(&account.Notify),
+				})
+				if err != nil {
+					return err
+				}
+				cfg.Accounts = append(cfg.Accounts, account)
+			}
+		case "chats":
+			list, ok := value.([]map[string]any)
+			if !ok {
+				return fmt.Errorf("chats должен быть массивом таблиц [[chats]]")
+			}
+			for i, table := range list {
+				var chat chatConfig
+				err := decodeTOMLTable(fmt.Sprintf("chats[%d]", i), table, map[string]tomlField{
+					"id":     tomlID(&chat.ID),
+					"notify": tomlBool(&chat.Notify),
+					"admin":  tomlBool(&chat.Admin),
+				})
+				if err != nil {
+					return err
+				}
+				cfg.Chats = append(cfg.Chats, chat)
+			}
+		default:
+			fields, known := tables[key]
+			if !known {
+				return fmt.Errorf("неизвестная секция %s", key)
+			}
+			table, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s должен быть таблицей [%s]", key, key)
+			}
+			if err := decodeTOMLTable(key, table, fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyEnv: переменные окружения сильнее файла. TELEGRAM_NOTIFY_CHAT_ID и
+// TELEGRAM_ADMIN_IDS заменяют списки чатов уведомлений и администраторов.
+func (c *config) applyEnv() error {
+	if token := strings.TrimSpace(os.Getenv(telegramTokenEnv)); token != "" {
+		c.Telegram.Token = token
+	}
+	if level := strings.TrimSpace(os.Getenv(logLevelEnv)); level != "" {
+		c.Log.Level = level
+	}
+	if format := strings.TrimSpace(os.Getenv(logFormatEnv)); format != "" {
+		c.Log.Format = format
+	}
+	if raw := strings.TrimSpace(os.Getenv(telegramChatEnv)); raw != "" {
+		ids, err := parseIDList(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", telegramChatEnv, err)
+		}
+		c.overrideChats(ids, func(chat *chatConfig, on bool) { chat.Notify = on })
+	}
+	if raw := strings.TrimSpace(os.Getenv(telegramAdminsEnv)); raw != "" {
+		ids, err := parseIDList(raw)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", telegramAdminsEnv, err)
+		}
+		c.overrideChats(ids, func(chat *chatConfig, on bool) { chat.Admin = on })
+	}
+	return nil
+}
+
+func (c *config) overrideChats(ids []int64, set func(chat *chatConfig, on bool)) {
+	wanted := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		wanted[id] = struct{}{}
+	}
+	for i := range c.Chats {
+		_, on := wanted[c.Chats[i].ID]
+		set(&c.Chats[i], on)
+		delete(wanted, c.Chats[i].ID)
+	}
+	for _, id := range ids {
+		if _, missing := wanted[id]; !missing {
+			continue
+		}
+		chat := chatConfig{ID: id}
+		set(&chat, true)
+		c.Chats = append(c.Chats, chat)
+		delete(wanted, id)
+	}
+}
+
+func parseIDList(raw string) ([]int64, error) {
+	var ids []int64
+	for _, value := range strings.Split(raw, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// validate собирает все ошибки сразу, чтобы их можно было исправить за раз.
+func (c config) validate() error {
+	var errs []error
+	if c.OpenDota.RateLimit < 1 || c.OpenDota.RateLimit > 1200 {
+		errs = append(errs, fmt.Errorf("opendota.rate_limit должен быть от 1 до 1200 запросов в минуту, сейчас %d", c.OpenDota.RateLimit))
+	}
+	if c.Monitor.PollInterval < time.Minute {
+		errs = append(errs, fmt.Errorf("monitor.poll_interval должен быть не меньше 1m, сейчас %s", c.Monitor.PollInterval))
+	}
+	if _, err := newLogger(io.Discard, c.Log); err != nil {
+		errs = append(errs, fmt.Errorf("log: %w", err))
+	}
+	if _, ok := normalizeLang(c.Locale.Lang); !ok {
+		errs = append(errs, fmt.Errorf("locale.lang: неизвестный язык %q, доступны: %s", c.Locale.Lang, strings.Join(supportedLangs(), ", ")))
+	}
+	if _, err := c.Locale.Location(); err != nil {
+		errs = append(errs, fmt.Errorf("locale.timezone: %w", err))
+	}
+	if strings.TrimSpace(c.Paths.Data) == "" {
+		errs = append(errs, fmt.Errorf("paths.data не задан"))
+	}
+	if len(c.Accounts) == 0 && strings.TrimSpace(c.Paths.Accounts) == "" {
+		errs = append(errs, fmt.Errorf("нет ни [[accounts]], ни paths.accounts"))
+	}
+	seenAccounts := make(map[int64]int)
+	for i, account := range c.Accounts {
+		if account.ID <= 0 {
+			errs = append(errs, fmt.Errorf("accounts[%d].id должен быть положительным", i))
+			continue
+		}
+		if first, ok := seenAccounts[account.ID]; ok {
+			errs = append(errs, fmt.Errorf("accounts[%d].id %d повторяет accounts[%d]", i, account.ID, first))
+			continue
+		}
+		seenAccounts[account.ID] = i
+	}
+	seenChats := make(map[int64]int)
+	for i, chat := range c.Chats {
+		if chat.ID == 0 {
+			errs = append(errs, fmt.Errorf("chats[%d].id не задан", i))
+			continue
+		}
+		if first, ok := seenChats[chat.ID]; ok {
+			errs = append(errs, fmt.Errorf("chats[%d].id %d повторяет chats[%d]", i, chat.ID, first))
+			continue
+		}
+		seenChats[chat.ID] = i
+	}
+	return errors.Join(errs...)
+}
+
+func (c localeConfig) Location() (*time.Location, error) {
+	if strings.TrimSpace(c.Timezone) == "" {
+		return time.Local, nil
+	}
+	return loadTimezone(c.Timezone)
+}
+
+func (c config) SchedulesPath() string {
+	return filepath.Join(c.Paths.Data, "schedules.json")
+}
+
+func (c config) AliasesPath() string {
+	return filepath.Join(c.Paths.Data, "aliases.json")
+}
+
+// StorePath — каталог локальной истории матчей.
+func (c config) StorePath() string {
+	return filepath.Join(c.Paths.Data, "store")
+}
+
+func (c config) ChatsPath() string {
+	return filepath.Join(c.Paths.Data, "chats.json")
+}
+
+func (c config) NotifyChats() []int64 {
+	var ids []int64
+	for _, chat := range c.Chats {
+		if chat.Notify {
+			ids = append(ids, chat.ID)
+		}
+	}
+	return ids
+}
+
+// AdminIDs — чаты и пользователи с admin = true; если таких нет,
+// администраторами считаются личные чаты уведомлений. Групповые чаты
+// уведомлений сами администраторами не становятся: isAdmin сверяет
+// chat_id, и права получил бы любой участник группы.
+func (c config) AdminIDs() map[int64]struct{} {
+	admins := make(map[int64]struct{})
+	for _, chat := range c.Chats {
+		if chat.Admin {
+			admins[chat.ID] = struct{}{}
+		}
+	}
+	if len(admins) == 0 {
+		for _, id := range c.NotifyChats() {
+			// У личных чатов chat_id положительный и совпадает с user_id.
+			if id > 0 {
+				admins[id] = struct{}{}
+			}
+		}
+	}
+	return admins
+}
+
+// LoadAccounts возвращает [[accounts]] из конфига, а если их нет —
+// аккаунты из файла paths.accounts.
+func (c config) LoadAccounts() ([]accountConfig, error) {
+	if len(c.Accounts) > 0 {
+		return append([]accountConfig(nil), c.Accounts...), nil
+	}
+	return loadAccountFile(c.Paths.Accounts)
+}
+
+// AccountsSource описывает, откуда берутся аккаунты, для сообщений /reload.
+func (c config) AccountsSource() string {
+	if len(c.Accounts) > 0 {
+		return c.Path
+	}
+	return c.Paths.Accounts
+}
+
+// applyConfig применяет глобальные настройки: логирование, лимит запросов
+// к OpenDota, язык и часовой пояс и то, что задано командами бота (alias и
+// настройки чатов). Локальную историю открывает cliOptions.load.
+func applyConfig(cfg config) error {
+	if err := setupLogging(cfg.Log); err != nil {
+		return err
+	}
+	opendotaLimiter.SetRate(cfg.OpenDota.RateLimit, opendotaRateSpan)
+	lang, _ := normalizeLang(cfg.Locale.Lang)
+	tz, err := cfg.Locale.Location()
+	if err != nil {
+		return err
+	}
+	setDefaultLocale(lang, tz)
+	if err := playerAliases.Load(cfg.AliasesPath()); err != nil {
+		return err
+	}
+	return chatPrefs.Load(cfg.ChatsPath())
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{telegramTokenEnv, telegramChatEnv, telegramAdminsEnv} {
+		t.Setenv(key, "")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeTestConfig(t, `
+[telegram]
+token = "file-token"
+
+[opendota]
+rate_limit = 120
+
+[monitor]
+poll_interval = "2m"
+
+[paths]
+data = "/var/lib/easykatka"
+
+[[accounts]]
+id = 76561197960265729
+alias = "Alpha"
+
+[[accounts]]
+id = "42"
+notify = false
+
+[[chats]]
+id = -100
+notify = true
+
+[[chats]]
+id = 7
+admin = true
+`)
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Telegram.Token != "file-token" || cfg.OpenDota.RateLimit != 120 || cfg.Monitor.PollInterval != 2*time.Minute {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if cfg.SchedulesPath() != filepath.Join("/var/lib/easykatka", "schedules.json") {
+		t.Fatalf("schedules path=%q", cfg.SchedulesPath())
+	}
+	// SteamID64 переводится в account_id, notify по умолчанию включён.
+	want := []accountConfig{{ID: 1, Alias: "Alpha", Notify: true}, {ID: 42}}
+	if len(cfg.Accounts) != 2 || cfg.Accounts[0] != want[0] || cfg.Accounts[1] != want[1] {
+		t.Fatalf("accounts=%+v", cfg.Accounts)
+	}
+	if chats := cfg.NotifyChats(); len(chats) != 1 || chats[0] != -100 {
+		t.Fatalf("notify chats=%v", chats)
+	}
+	if _, ok := cfg.AdminIDs()[7]; !ok || len(cfg.AdminIDs()) != 1 {
+		t.Fatalf("admins=%v", cfg.AdminIDs())
+	}
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeTestConfig(t, "[telegram]\ntoken = \"file-token\"\n\n[[accounts]]\nid = 1\n\n[[chats]]\nid = -100\nnotify = true\n")
+	t.Setenv(telegramTokenEnv, "env-token")
+	t.Setenv(telegramChatEnv, "-200")
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Telegram.Token != "env-token" {
+		t.Fatalf("token=%q", cfg.Telegram.Token)
+	}
+	if chats := cfg.NotifyChats(); len(chats) != 1 || chats[0] != -200 {
+		t.Fatalf("notify chats=%v", chats)
+	}
+	// Без admin = true групповой чат уведомлений администратором не становится.
+	if len(cfg.AdminIDs()) != 0 {
+		t.Fatalf("admins=%v", cfg.AdminIDs())
+	}
+	// А личный — становится.
+	t.Setenv(telegramChatEnv, "-200,300")
+	cfg, err = loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cfg.AdminIDs()[300]; !ok || len(cfg.AdminIDs()) != 1 {
+		t.Fatalf("admins=%v", cfg.AdminIDs())
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeTestConfig(t, `
+[opendota]
+rate_limit = 0
+
+[monitor]
+poll_interval = "10s"
+
+[locale]
+lang = "de"
+timezone = "Mars/Olympus"
+
+[[accounts]]
+id = 5
+
+[[accounts]]
+id = 5
+`)
+	_, err := loadConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"rate_limit", "poll_interval", "locale.lang", "locale.timezone", "повторяет accounts[0]"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q should mention %q", err.Error(), want)
+		}
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	clearConfigEnv(t)
+	for _, content := range []string{"[telegram]\ntokn = \"x\"", "[telegarm]\ntoken = \"x\"", "[[accounts]]\nid = 1\nnotfy = false"} {
+		if _, err := loadConfig(writeTestConfig(t, content)); err == nil {
+			t.Fatalf("expected error for %q", content)
+		}
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	clearConfigEnv(t)
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Fatal("expected error for missing explicit config")
+	}
+}
+
+func TestConfigAccountStoreReload(t *testing.T) {
+	clearConfigEnv(t)
+	path := writeTestConfig(t, "[[accounts]]\nid = 1\n")
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store, err := loadConfigAccountStore(path, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("[[accounts]]\nid = 1\nnotify = false\n\n[[accounts]]\nid = 2\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	diff, err := store.Reload()
+	if err != nil || len(diff.Added) != 1 || len(diff.Changed) != 1 {
+		t.Fatalf("reload: diff=%+v err=%v", diff, err)
+	}
+	if store.Account(1).Notify || !store.Account(2).Notify {
+		t.Fatalf("unexpected accounts: %+v %+v", store.Account(1), store.Account(2))
+	}
+}
+
+func TestRateLimiterSetRate(t *testing.T) {
+	limiter := newRateLimiter(1, time.Hour)
+	// Тот же тикер, новый интервал: Wait больше не ждёт час.
+	limiter.SetRate(1000, time.Second)
+	done := make(chan struct{})
+	go func() {
+		limiter.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("rate was not updated")
+	}
+	var none *rateLimiter
+	none.SetRate(10, time.Second)
+}
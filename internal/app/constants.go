@@ -16,11 +16,109 @@ const (
 	playerURL        = "/players/%d"
 	peersURL         = "/players/%d/peers"
 	playerMatchesURL = "/players/%d/matches"
+	playerHeroesURL  = "/players/%d/heroes"
+	playerCountsURL  = "/players/%d/counts"
+	parseRequestURL  = "/request/%d"
 
-	telegramTokenEnv = "TELEGRAM_BOT_TOKEN"
-	telegramChatEnv  = "TELEGRAM_NOTIFY_CHAT_ID"
-	telegramBaseURL  = "https://api.telegram.org/bot%s"
-	telegramMaxLen   = 3900
+	kdaProjection        = "&project=kills&project=deaths&project=assists&project=player_slot&project=radiant_win"
+	matchStatsProjection = "&project=hero_id&project=kills&project=deaths&project=assists&project=duration" +
+		"&project=start_time&project=player_slot&project=radiant_win&project=gold_per_min&project=xp_per_min" +
+		"&project=game_mode&project=lobby_type&project=lane_role&project=is_roaming"
+
+	telegramTokenEnv   = "TELEGRAM_BOT_TOKEN"
+	telegramChatEnv    = "TELEGRAM_NOTIFY_CHAT_ID"
+	telegramAdminsEnv  = "TELEGRAM_ADMIN_IDS"
+	logLevelEnv        = "LOG_LEVEL"
+	logFormatEnv       = "LOG_FORMAT"
+	telegramBaseURL    = "https://api.telegram.org/bot%s"
+	telegramMaxLen     = 3900
+	telegramCaptionMax = 1024
+	telegramRetryMin   = 2 * time.Second
+	telegramRetryMax   = time.Minute
+
+	callbackExpand   = "match"
+	callbackCollapse = "collapse"
+	callbackRating   = "rating"
+
+	callbackRatingNoop = "noop"
+
+	chartDefaultGames = 20
+	chartMaxGames     = 100
+
+	statDefaultGames    = 20
+	statMaxGames        = 100
+	statShownMatches    = 10
+	ratingDefaultGames  = 50
+	ratingMaxGames      = 500
+	friendsDefaultGames = 20
+	friendsMaxGames     = 500
+
+	synergyDefaultGames    = 100
+	synergyMaxGames        = 500
+	synergyDefaultMinGames = 3
+
+	compareDefaultGames = 50
+	compareMaxGames     = 500
+
+	heroesDefaultTop = 10
+	heroesMaxTop     = 30
+
+	rolesDefaultGames = 100
+	rolesMaxGames     = 500
+
+	awardsDefaultDays = 7
+	awardsMaxGames    = 200
+
+	configPathEnv       = "EASYKATKA_CONFIG"
+	defaultConfigFile   = "config.toml"
+	defaultAccountsPath = "account_id"
+	defaultDataDir      = "data"
+	defaultPollInterval = 5 * time.Minute
+
+	accountsWatchInterval = 15 * time.Second
+
+	scheduleTick = 30 * time.Second
+
+	inlineMaxPlayers = 3
+	inlineCacheTime  = 60
+	profileCacheTTL  = 10 * time.Minute
+	recentCacheTTL   = 2 * time.Minute
+	profileCacheSize = 1000
+	matchCacheSize   = 500
+	recentCacheSize  = 200
+	itemsCacheSize   = 8
+	matchCacheTTL    = 8 * 24 * time.Hour
+	itemsCacheTTL    = 24 * time.Hour
+
+	expandedCacheTTL  = 2 * roleParseRetries * roleParseDelay
+	expandedCacheSize = 1000
+
+	recentMatchesCount   = 20
+	storeSegmentSize     = 4 << 20
+	storeCompactSegments = 8
+	storeSyncMaxAge      = 15 * time.Minute
+	storeLockFile        = "LOCK"
+
+	backfillPageSize   = 500
+	backfillRetries    = 4
+	backfillRetryDelay = 30 * time.Second
+	backfillReportStep = 10 * time.Second
+
+	roleParseRetries = 10
+	roleParseDelay   = 2 * time.Minute
 )
 
 var opendotaLimiter = newRateLimiter(opendotaRateCap, opendotaRateSpan)
+
+var (
+	profileCache = newTTLCache[int64, playerProfileData](profileCacheTTL, profileCacheSize)
+	matchCache   = newTTLCache[int64, matchDetails](matchCacheTTL, matchCacheSize)
+	recentCache  = newTTLCache[int64, []recentMatch](recentCacheTTL, recentCacheSize)
+	itemsCache   = newTTLCache[string, map[int]string](itemsCacheTTL, itemsCacheSize)
+)
+
+var playerAliases = newAliasStore()
+
+var chatPrefs = newChatSettingsStore()
+
+var matchHistory = newLocalStore()
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	exportMatches = "matches"
+	exportRating  = "rating"
+	exportFriends = "friends"
+
+	exportCSV      = "csv"
+	exportJSON     = "json"
+	exportMarkdown = "md"
+)
+
+var exportFormatAliases = map[string]string{
+	"csv":      exportCSV,
+	"json":     exportJSON,
+	"md":       exportMarkdown,
+	"markdown": exportMarkdown,
+}
+
+// reportColumn: Key — имя поля в CSV и JSON, Title — заголовок в Markdown.
+type reportColumn struct {
+	Key   string
+	Title string
+}
+
+// reportData — отчёт в виде таблицы, независимый от формата вывода.
+type reportData struct {
+	Columns []reportColumn
+	Rows    [][]any
+}
+
+// reportColumns подписывает столбцы по ключам "report.<key>" каталога.
+func reportColumns(loc locale, keys ...string) []reportColumn {
+	columns := make([]reportColumn, len(keys))
+	for i, key := range keys {
+		columns[i] = reportColumn{Key: key, Title: loc.T("report." + key)}
+	}
+	return columns
+}
+
+func matchesReportData(loc locale, players []playerMatches, heroes map[int]string) reportData {
+	data := reportData{Columns: reportColumns(loc,
+		"account_id", "player", "match_id", "start_time", "hero", "win", "kills", "deaths", "assists", "duration")}
+	for _, player := range players {
+		for _, row := range buildMatchRows(loc, player.AccountID, player.Name, player.Matches, heroes) {
+			data.Rows = append(data.Rows, []any{
+				row.AccountID, row.Player, row.MatchID, row.Start.Format(time.RFC3339),
+				row.Hero, row.Win, row.Kills, row.Deaths, row.Assists, row.Duration,
+			})
+		}
+	}
+	return data
+}
+
+func ratingReportData(loc locale, entries []ratingEntry) reportData {
+	data := reportData{Columns: reportColumns(loc,
+		"place", "account_id", "player", "games", "wins", "winrate", "kda", "gpm", "rank_tier", "rank", "streak")}
+	winrate, kda, gpm := lookupRatingMetric(ratingWinrate), lookupRatingMetric(ratingKDA), lookupRatingMetric(ratingGPM)
+	for _, row := range buildRatingRows(entries) {
+		data.Rows = append(data.Rows, []any{
+			row.Place, row.AccountID, row.Name, row.Games, row.Wins,
+			winrate.Round(row.ratingEntry), kda.Round(row.ratingEntry), gpm.Round(row.ratingEntry),
+			row.RankTier, rankTierName(row.RankTier), row.Streak,
+		})
+	}
+	return data
+}
+
+func friendsReportData(loc locale, entries []bestFriendEntry) reportData {
+	data := reportData{Columns: reportColumns(loc, "account_id", "player", "friend_id", "friend", "winrate", "games")}
+	for _, e := range entries {
+		data.Rows = append(data.Rows, []any{e.AccountID, e.Player, e.FriendID, e.Friend, roundTo(e.Winrate, 1), e.Games})
+	}
+	return data
+}
+
+func roundTo(value float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(value*scale) / scale
+}
+
+func renderReport(data reportData, format string) ([]byte, error) {
+	switch format {
+	case exportCSV:
+		return renderReportCSV(data)
+	case exportJSON:
+		return renderReportJSON(data)
+	case exportMarkdown:
+		return renderReportMarkdown(data), nil
+	}
+	return nil, newLocalizedError("err.export_format", format)
+}
+
+func renderReportCSV(data reportData) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	header := make([]string, len(data.Columns))
+	for i, column := range data.Columns {
+		header[i] = column.Key
+	}
+	if err := writer.Write(header); err != nil {
+		return nil, fmt.Errorf("write csv: %w", err)
+	}
+	for _, row := range data.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = fmt.Sprint(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("write csv: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderReportJSON пишет массив объектов, сохраняя порядок колонок.
+func renderReportJSON(data reportData) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i, row := range data.Rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  {")
+		for j, value := range row {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			key, _ := json.Marshal(data.Columns[j].Key)
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("marshal report: %w", err)
+			}
+			buf.Write(key)
+			buf.WriteString(": ")
+			buf.Write(raw)
+		}
+		buf.WriteString("}")
+	}
+	if len(data.Rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	return buf.Bytes(), nil
+}
+
+func renderReportMarkdown(data reportData) []byte {
+	var buf bytes.Buffer
+	cell := func(value any) string {
+		text := fmt.Sprint(value)
+		if b, ok := value.(bool); ok {
+			text = "❌"
+			if b {
+				text = "✅"
+			}
+		}
+		return strings.ReplaceAll(text, "|", "\\|")
+	}
+	titles := make([]string, len(data.Columns))
+	separators := make([]string, len(data.Columns))
+	for i, column := range data.Columns {
+		titles[i] = cell(column.Title)
+		separators[i] = "---"
+	}
+	buf.WriteString("| " + strings.Join(titles, " | ") + " |\n")
+	buf.WriteString("| " + strings.Join(separators, " | ") + " |\n")
+	for _, row := range data.Rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = cell(value)
+		}
+		buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return buf.Bytes()
+}
+
+// parseExportArgs разбирает "<отчёт> [формат] [фильтры]"; для rating среди
+// фильтров можно указать метрику сортировки.
+func parseExportArgs(raw []string) (commandArgs, error) {
+	usage := newLocalizedError("usage.export")
+	if len(raw) == 0 {
+		return commandArgs{}, usage
+	}
+	report := strings.ToLower(raw[0])
+	format := exportCSV
+	var rest []string
+	for _, token := range raw[1:] {
+		if value, ok := exportFormatAliases[strings.ToLower(token)]; ok {
+			format = value
+			continue
+		}
+		rest = append(rest, token)
+	}
+	var args commandArgs
+	var err error
+	switch report {
+	case exportMatches:
+		args, err = filterArgs(statDefaultGames, statMaxGames)(rest)
+	case exportRating:
+		args, err = parseRatingArgs(rest)
+	case exportFriends:
+		args, err = filterArgs(friendsDefaultGames, friendsMaxGames)(rest)
+	default:
+		return commandArgs{}, usage
+	}
+	if err != nil {
+		return commandArgs{}, err
+	}
+	args.Raw = raw
+	args.Text = report
+	args.Format = format
+	return args, nil
+}
+
+// buildExport загружает отчёт и возвращает имя файла и его содержимое.
+func buildExport(loc locale, accountIDs []int64, args commandArgs, heroes map[int]string) (string, []byte, error) {
+	var data reportData
+	switch args.Text {
+	case exportMatches:
+		players := make([]playerMatches, 0, len(accountIDs))
+		for _, accountID := range accountIDs {
+			player, err := loadPlayerMatches(accountID, args.Filter)
+			if err != nil {
+				return "", nil, err
+			}
+			players = append(players, player)
+		}
+		data = matchesReportData(loc, players, heroes)
+	case exportRating:
+		entries, err := loadRatingEntries(accountIDs, args.Filter)
+		if err != nil {
+			return "", nil, err
+		}
+		sortRatingEntries(entries, lookupRatingMetric(args.Sort))
+		data = ratingReportData(loc, entries)
+	case exportFriends:
+		entries, err := loadBestFriends(accountIDs, args.Filter)
+		if err != nil {
+			return "", nil, err
+		}
+		data = friendsReportData(loc, entries)
+	default:
+		return "", nil, newLocalizedError("err.export_report", args.Text)
+	}
+	content, err := renderReport(data, args.Format)
+	if err != nil {
+		return "", nil, err
+	}
+	filename := fmt.Sprintf("%s_%s.%s", args.Text, time.Now().In(loc.Location()).Format("20060102"), args.Format)
+	return filename, content, nil
+}
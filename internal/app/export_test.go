@@ -0,0 +1,102 @@
+package app
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func sampleReportData() reportData {
+	return reportData{
+		Columns: []reportColumn{{"player", "Игрок"}, {"win", "Победа"}, {"winrate", "Winrate, %"}},
+		Rows: [][]any{
+			{"Alpha|1", true, 55.5},
+			{"Bravo, Jr", false, 40.0},
+		},
+	}
+}
+
+func TestRenderReportCSV(t *testing.T) {
+	out, err := renderReport(sampleReportData(), exportCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(out))).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "player" || records[2][0] != "Bravo, Jr" || records[1][2] != "55.5" {
+		t.Fatalf("unexpected records: %q", records)
+	}
+}
+
+func TestRenderReportJSON(t *testing.T) {
+	out, err := renderReport(sampleReportData(), exportJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal(out, &rows); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, out)
+	}
+	if len(rows) != 2 || rows[0]["win"] != true || rows[1]["winrate"] != 40.0 {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	// Порядок полей совпадает с порядком колонок.
+	if strings.Index(string(out), `"player"`) > strings.Index(string(out), `"win"`) {
+		t.Fatalf("columns out of order:\n%s", out)
+	}
+
+	empty, err := renderReport(reportData{}, exportJSON)
+	if err != nil || strings.TrimSpace(string(empty)) != "[]" {
+		t.Fatalf("empty report: %q, %v", empty, err)
+	}
+}
+
+func TestRenderReportMarkdown(t *testing.T) {
+	out, err := renderReport(sampleReportData(), exportMarkdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if lines[0] != "| Игрок | Победа | Winrate, % |" || lines[1] != "| --- | --- | --- |" {
+		t.Fatalf("unexpected header:\n%s", out)
+	}
+	if lines[2] != `| Alpha\|1 | ✅ | 55.5 |` {
+		t.Fatalf("unexpected row: %q", lines[2])
+	}
+}
+
+func TestParseExportArgs(t *testing.T) {
+	args, err := parseExportArgs([]string{"rating", "json", "kda", "7d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Text != exportRating || args.Format != exportJSON || args.Sort != ratingKDA || args.Filter.Days != 7 {
+		t.Fatalf("unexpected args: %+v", args)
+	}
+	args, err = parseExportArgs([]string{"matches"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Format != exportCSV || args.Filter.Limit != statDefaultGames {
+		t.Fatalf("unexpected defaults: %+v", args)
+	}
+	for _, raw := range [][]string{nil, {"heroes"}, {"friends", "kda"}} {
+		if _, err := parseExportArgs(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+func TestRatingReportData(t *testing.T) {
+	data := ratingReportData(newLocale(langRU), []ratingEntry{{AccountID: 7, Name: "Alpha", Games: 3, Wins: 2, KDA: 2.345, RankTier: 54}})
+	if len(data.Rows) != 1 || len(data.Rows[0]) != len(data.Columns) {
+		t.Fatalf("row does not match columns: %v", data)
+	}
+	row := data.Rows[0]
+	if row[5] != 66.7 || row[6] != 2.35 || row[9] != "Legend 4" {
+		t.Fatalf("unexpected row: %v", row)
+	}
+}
@@ -0,0 +1,183 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// matchFilter ограничивает выборку матчей OpenDota по периоду, типу лобби,
+// режиму и количеству игр. Limit == 0 означает "без ограничения".
+type matchFilter struct {
+	Days      int
+	LobbyType *int
+	GameMode  *int
+	Queue     string
+	Limit     int
+}
+
+type queueFilter struct {
+	LobbyType *int
+	GameMode  *int
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+var queueFilters = map[string]queueFilter{
+	"ranked":   {LobbyType: intPtr(7)},
+	"normal":   {LobbyType: intPtr(0)},
+	"unranked": {LobbyType: intPtr(0)},
+	"turbo":    {GameMode: intPtr(23)},
+	"allpick":  {GameMode: intPtr(22)},
+}
+
+// parseMatchFilter разбирает токены вида "7d", "2w", "3m", "ranked",
+// "turbo" и число игр. defaultLimit используется, если не задан ни период,
+// ни число игр; при заданном периоде без числа игр лимит снимается.
+func parseMatchFilter(tokens []string, defaultLimit int, maxLimit int) (matchFilter, error) {
+	var filter matchFilter
+	limitSet := false
+	for _, token := range tokens {
+		lower := strings.ToLower(token)
+		if queue, ok := queueFilters[lower]; ok {
+			if filter.Queue != "" {
+				return matchFilter{}, newLocalizedError("err.queue_set", filter.Queue)
+			}
+			filter.Queue = lower
+			filter.LobbyType = queue.LobbyType
+			filter.GameMode = queue.GameMode
+			continue
+		}
+		if days, ok := parsePeriodDays(lower); ok {
+			filter.Days = days
+			continue
+		}
+		value, err := strconv.Atoi(lower)
+		if err != nil {
+			return matchFilter{}, newLocalizedError("err.bad_filter", token)
+		}
+		if value <= 0 || (maxLimit > 0 && value > maxLimit) {
+			return matchFilter{}, newLocalizedError("err.games_range", maxLimit)
+		}
+		filter.Limit = value
+		limitSet = true
+	}
+	if !limitSet && filter.Days == 0 {
+		filter.Limit = defaultLimit
+	}
+	return filter, nil
+}
+
+func parsePeriodDays(token string) (int, bool) {
+	if len(token) < 2 {
+		return 0, false
+	}
+	multiplier := 0
+	switch token[len(token)-1] {
+	case 'd':
+		multiplier = 1
+	case 'w':
+		multiplier = 7
+	case 'm':
+		multiplier = 30
+	case 'y':
+		multiplier = 365
+	default:
+		return 0, false
+	}
+	value, err := strconv.Atoi(token[:len(token)-1])
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return value * multiplier, true
+}
+
+// Query возвращает параметры запроса OpenDota, начиная с "&".
+func (f matchFilter) Query() string {
+	var builder strings.Builder
+	if f.Limit > 0 {
+		builder.WriteString(fmt.Sprintf("&limit=%d", f.Limit))
+	}
+	if f.Days > 0 {
+		builder.WriteString(fmt.Sprintf("&date=%d", f.Days))
+	}
+	if f.LobbyType != nil {
+		builder.WriteString(fmt.Sprintf("&lobby_type=%d", *f.LobbyType))
+	}
+	if f.GameMode != nil {
+		builder.WriteString(fmt.Sprintf("&game_mode=%d", *f.GameMode))
+	}
+	return builder.String()
+}
+
+func (f matchFilter) Describe() string {
+	return f.DescribeIn(defaultLocale())
+}
+
+// DescribeIn описывает фильтр на языке loc.
+func (f matchFilter) DescribeIn(loc locale) string {
+	var parts []string
+	if f.Limit > 0 {
+		parts = append(parts, loc.T("filter.last", loc.N(f.Limit, "games")))
+	}
+	if f.Days > 0 {
+		parts = append(parts, loc.T("filter.days", f.Days))
+	}
+	if f.Queue != "" {
+		parts = append(parts, f.Queue)
+	}
+	if len(parts) == 0 {
+		return loc.T("filter.all")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Allows проверяет матч из локальной истории так же, как OpenDota проверяет
+// date, lobby_type и game_mode; Limit применяет вызывающий.
+func (f matchFilter) Allows(m recentMatch, now time.Time) bool {
+	if f.Days > 0 && m.StartTime < now.AddDate(0, 0, -f.Days).Unix() {
+		return false
+	}
+	if f.LobbyType != nil && m.LobbyType != *f.LobbyType {
+		return false
+	}
+	if f.GameMode != nil && m.GameMode != *f.GameMode {
+		return false
+	}
+	return true
+}
+
+// WithLimit возвращает копию фильтра с другим лимитом игр.
+func (f matchFilter) WithLimit(limit int) matchFilter {
+	f.Limit = limit
+	return f
+}
+
+// Tokens возвращает фильтр в виде аргументов команды, которые
+// parseMatchFilter разберёт обратно.
+func (f matchFilter) Tokens() []string {
+	var tokens []string
+	if f.Limit > 0 {
+		tokens = append(tokens, strconv.Itoa(f.Limit))
+	}
+	if f.Days > 0 {
+		tokens = append(tokens, fmt.Sprintf("%dd", f.Days))
+	}
+	if f.Queue != "" {
+		tokens = append(tokens, f.Queue)
+	}
+	return tokens
+}
+
+func filterArgs(defaultLimit int, maxLimit int) argsParser {
+	return func(raw []string) (commandArgs, error) {
+		filter, err := parseMatchFilter(raw, defaultLimit, maxLimit)
+		if err != nil {
+			return commandArgs{}, err
+		}
+		return commandArgs{Raw: raw, Limit: filter.Limit, Filter: filter}, nil
+	}
+}
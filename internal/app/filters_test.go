@@ -0,0 +1,71 @@
+package app
+
+import "testing"
+
+func TestParseMatchFilter_Default(t *testing.T) {
+	// Без аргументов используется лимит по умолчанию.
+	filter, err := parseMatchFilter(nil, 50, 500)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.Limit != 50 || filter.Days != 0 || filter.LobbyType != nil || filter.GameMode != nil {
+		t.Fatalf("unexpected filter: %+v", filter)
+	}
+	if got := filter.Query(); got != "&limit=50" {
+		t.Fatalf("Query()=%q", got)
+	}
+}
+
+func TestParseMatchFilter_PeriodAndQueue(t *testing.T) {
+	// Период без числа игр снимает лимит по умолчанию.
+	filter, err := parseMatchFilter([]string{"30d", "Turbo"}, 20, 500)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := filter.Query(); got != "&date=30&game_mode=23" {
+		t.Fatalf("Query()=%q", got)
+	}
+	if got := filter.Describe(); got != "за 30 дн., turbo" {
+		t.Fatalf("Describe()=%q", got)
+	}
+
+	filter, err = parseMatchFilter([]string{"ranked", "2w", "40"}, 20, 500)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := filter.Query(); got != "&limit=40&date=14&lobby_type=7" {
+		t.Fatalf("Query()=%q", got)
+	}
+}
+
+func TestParseMatchFilter_NormalLobbyZero(t *testing.T) {
+	// lobby_type=0 должен попадать в запрос, несмотря на нулевое значение.
+	filter, err := parseMatchFilter([]string{"normal"}, 20, 500)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := filter.Query(); got != "&limit=20&lobby_type=0" {
+		t.Fatalf("Query()=%q", got)
+	}
+}
+
+func TestParseMatchFilter_Invalid(t *testing.T) {
+	cases := [][]string{{"abc"}, {"0"}, {"1000"}, {"ranked", "turbo"}, {"0d"}}
+	for _, tokens := range cases {
+		if _, err := parseMatchFilter(tokens, 20, 500); err == nil {
+			t.Fatalf("expected error for %v", tokens)
+		}
+	}
+}
+
+func TestParsePeriodDays(t *testing.T) {
+	cases := map[string]int{"7d": 7, "2w": 14, "3m": 90, "1y": 365}
+	for token, want := range cases {
+		if got, ok := parsePeriodDays(token); !ok || got != want {
+			t.Fatalf("parsePeriodDays(%q)=%d, %v; want %d", token, got, ok, want)
+		}
+	}
+	if _, ok := parsePeriodDays("d"); ok {
+		t.Fatal("expected false for bare unit")
+	}
+}
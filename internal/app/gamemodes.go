@@ -0,0 +1,60 @@
+package app
+
+import "fmt"
+
+// Названия режимов и лобби из dotaconstants (game_mode.json, lobby_type.json).
+var gameModeNames = map[int]string{
+	0:  "Unknown",
+	1:  "All Pick",
+	2:  "Captains Mode",
+	3:  "Random Draft",
+	4:  "Single Draft",
+	5:  "All Random",
+	6:  "Intro",
+	7:  "Diretide",
+	8:  "Reverse Captains Mode",
+	9:  "Greeviling",
+	10: "Tutorial",
+	11: "Mid Only",
+	12: "Least Played",
+	13: "Limited Heroes",
+	14: "Compendium",
+	15: "Custom",
+	16: "Captains Draft",
+	17: "Balanced Draft",
+	18: "Ability Draft",
+	19: "Event",
+	20: "All Random Death Match",
+	21: "1v1 Mid",
+	22: "All Draft",
+	23: "Turbo",
+	24: "Mutation",
+	25: "Coaches Challenge",
+}
+
+var lobbyTypeNames = map[int]string{
+	0: "Normal",
+	1: "Practice",
+	2: "Tournament",
+	3: "Tutorial",
+	4: "Co-op Bots",
+	5: "Ranked Team MM",
+	6: "Ranked Solo MM",
+	7: "Ranked",
+	8: "1v1 Mid",
+	9: "Battle Cup",
+}
+
+func gameModeName(mode int) string {
+	if name, ok := gameModeNames[mode]; ok {
+		return name
+	}
+	return fmt.Sprintf("Mode #%d", mode)
+}
+
+func lobbyTypeName(lobby int) string {
+	if name, ok := lobbyTypeNames[lobby]; ok {
+		return name
+	}
+	return fmt.Sprintf("Lobby #%d", lobby)
+}
@@ -0,0 +1,449 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func defaultCommands() *commandRegistry {
+	return newCommandRegistry(
+		botCommand{
+			Name:        "stat",
+			Description: "последние матчи всех игроков",
+			Usage:       "[период] [режим] [число игр]",
+			Args:        parseStatArgs,
+			Handle:      handleStatCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "rating",
+			Description: "рейтинг игроков по винрейту, KDA, рангу, GPM или серии",
+			Usage:       "[метрика] [период] [режим] [число игр]",
+			Args:        parseRatingArgs,
+			Handle:      handleRatingCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "friends",
+			Description: "лучшие напарники по винрейту",
+			Usage:       "[период] [режим] [число игр]",
+			Args:        parseFriendsArgs,
+			Handle:      handleFriendsCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "synergy",
+			Description: "винрейт всех пар игроков",
+			Usage:       "[фильтры] [wr|games] [min=N]",
+			Args:        parseSynergyArgs,
+			Handle:      handleSynergyCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "export",
+			Description: "выгрузить отчёт файлом",
+			Usage:       "matches|rating|friends [csv|json|md] [фильтры]",
+			Args:        parseExportArgs,
+			Handle:      handleExportCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "awards",
+			Description: "награды недели по деталям матчей",
+			Usage:       "[период] [режим] [число игр]",
+			Args:        parseAwardsArgs,
+			Handle:      handleAwardsCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "chart",
+			Description: "графики винрейта, K/D/A и GPM игрока",
+			Usage:       "<игрок> [число игр]",
+			Args:        parseChartArgs,
+			Handle:      handleChartCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "hero",
+			Description: "статистика всех игроков на герое",
+			Usage:       "<герой>",
+			Args:        parseHeroArgs,
+			Handle:      handleHeroCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "heroes",
+			Description: "самые играемые герои игрока",
+			Usage:       "<игрок> [число героев]",
+			Args:        parseHeroesArgs,
+			Handle:      handleHeroesCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "roles",
+			Description: "роли и линии игрока",
+			Usage:       "<игрок> [число игр]",
+			Args:        parseRolesArgs,
+			Handle:      handleRolesCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "compare",
+			Description: "сравнение двух игроков",
+			Usage:       "<игрок A> <игрок B> [число игр]",
+			Args:        parseCompareArgs,
+			Handle:      handleCompareCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "match",
+			Description: "полная таблица матча",
+			Usage:       "<match_id>",
+			Args:        parseMatchArgs,
+			Handle:      handleMatchCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "last",
+			Description: "таблица последнего матча игрока",
+			Usage:       "<игрок>",
+			Args:        parseLastArgs,
+			Handle:      handleLastCommand,
+			Schedulable: true,
+		},
+		botCommand{
+			Name:        "schedule",
+			Description: "публиковать отчёт по расписанию",
+			Usage:       "daily|weekly <день> HH:MM [Area/City] <команда> [аргументы]",
+			Args:        parseScheduleArgs,
+			Handle:      handleScheduleCommand,
+			Permission:  permissionAdmin,
+		},
+		botCommand{
+			Name:        "schedules",
+			Description: "расписания этого чата",
+			Handle:      handleSchedulesCommand,
+		},
+		botCommand{
+			Name:        "unschedule",
+			Description: "удалить расписание",
+			Usage:       "<номер>",
+			Args:        parseIDArgs,
+			Handle:      handleUnscheduleCommand,
+			Permission:  permissionAdmin,
+		},
+		botCommand{
+			Name:        "alias",
+			Description: "постоянные имена игроков",
+			Usage:       "[<игрок> <имя>|-]",
+			Args:        parseAliasArgs,
+			Handle:      handleAliasCommand,
+			Permission:  permissionAdmin,
+		},
+		botCommand{
+			Name:        "lang",
+			Description: "язык чата",
+			Usage:       "[ru|en|-]",
+			Handle:      handleLangCommand,
+		},
+		botCommand{
+			Name:        "tz",
+			Description: "часовой пояс чата",
+			Usage:       "[Area/City|-]",
+			Handle:      handleTimezoneCommand,
+		},
+		botCommand{
+			Name:        "chatid",
+			Description: "показать chat_id",
+			Handle:      handleChatIDCommand,
+		},
+		botCommand{
+			Name:        "help",
+			Description: "список команд",
+			Handle:      handleHelpCommand,
+		},
+		botCommand{
+			Name:        "test",
+			Description: "тестовое уведомление о матче",
+			Handle:      handleTestCommand,
+			Hidden:      true,
+		},
+		botCommand{
+			Name:        "reload",
+			Description: "перечитать файл account_id",
+			Handle:      handleReloadCommand,
+			Permission:  permissionAdmin,
+		},
+		botCommand{
+			Name:        "backfill",
+			Description: "загрузить полную историю матчей",
+			Usage:       "[restart]",
+			Args:        parseBackfillArgs,
+			Handle:      handleBackfillCommand,
+			Permission:  permissionAdmin,
+		},
+	)
+}
+
+func handleStatCommand(bot *telegramBot, req commandRequest) error {
+	for _, accountID := range bot.accountStore.Get() {
+		player, err := loadPlayerMatches(accountID, req.Args.Filter)
+		if err != nil {
+			bot.sendError(req.ChatID, err)
+			continue
+		}
+		matches := player.Matches
+		winrate, games := calcWinrateWithCount(matches, len(matches))
+		if len(matches) > statShownMatches {
+			matches = matches[:statShownMatches]
+		}
+		table := buildPlayerTable(req.Locale, matches, bot.heroes, player.Name)
+		header := req.Locale.T("stat.header", escapeHTML(player.Name), escapeHTML(req.Args.Filter.DescribeIn(req.Locale)), winrate, req.Locale.N(games, "games.acc"))
+		if player.Avatar != "" {
+			if err := sendTelegramPhoto(bot.apiBase, req.ChatID, player.Avatar, header, "HTML", nil); err != nil {
+				return err
+			}
+			if err := bot.sendTable(req.ChatID, "", table); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := bot.sendTable(req.ChatID, header, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func handleRatingCommand(bot *telegramBot, req commandRequest) error {
+	text, err := bot.buildRatingMessage(req.Locale, req.ChatID, req.Args.Sort, req.Args.Filter)
+	if err != nil {
+		return err
+	}
+	return sendTelegramMessage(bot.apiBase, req.ChatID, text, "HTML", buildRatingMarkup(req.Locale, req.Args.Sort, req.Args.Filter))
+}
+
+func handleFriendsCommand(bot *telegramBot, req commandRequest) error {
+	table, err := buildBestFriendsTable(req.Locale, bot.accountStore.Get(), req.Args.Filter)
+	if err != nil {
+		return err
+	}
+	header := req.Locale.T("friends.header", escapeHTML(req.Args.Filter.DescribeIn(req.Locale)))
+	return bot.sendTable(req.ChatID, header, table)
+}
+
+func handleSynergyCommand(bot *telegramBot, req commandRequest) error {
+	table, err := buildSynergyTable(req.Locale, bot.accountStore.Get(), req.Args.Filter, req.Args.Sort, req.Args.MinGames)
+	if err != nil {
+		return err
+	}
+	header := req.Locale.T("synergy.header", escapeHTML(req.Args.Filter.DescribeIn(req.Locale)), req.Locale.N(req.Args.MinGames, "games.gen"))
+	return bot.sendTable(req.ChatID, header, table)
+}
+
+func handleExportCommand(bot *telegramBot, req commandRequest) error {
+	filename, content, err := buildExport(req.Locale, bot.accountStore.Get(), req.Args, bot.heroes)
+	if err != nil {
+		return err
+	}
+	caption := fmt.Sprintf("%s (%s)", req.Args.Text, req.Args.Filter.DescribeIn(req.Locale))
+	return sendTelegramDocument(bot.apiBase, req.ChatID, filename, content, caption, "")
+}
+
+func handleAwardsCommand(bot *telegramBot, req commandRequest) error {
+	text, err := buildAwardsMessage(req.Locale, bot.accountStore.Get(), req.Args.Filter, bot.heroes)
+	if err != nil {
+		return err
+	}
+	return sendTelegramMessage(bot.apiBase, req.ChatID, text, "HTML", nil)
+}
+
+func handleChartCommand(bot *telegramBot, req commandRequest) error {
+	player, err := resolveTrackedPlayer(loadTrackedPlayers(bot.accountStore.Get()), req.Args.Text)
+	if err != nil {
+		return err
+	}
+	matches, err := fetchPlayerMatchStats(player.AccountID, req.Args.Limit)
+	if err != nil {
+		return err
+	}
+	panels, err := buildPlayerChartPanels(matches)
+	if err != nil {
+		return err
+	}
+	data, err := renderChartPNG(panels)
+	if err != nil {
+		return err
+	}
+	caption := req.Locale.T("chart.caption", escapeHTML(player.Name), req.Locale.N(len(matches), "games"), min(chartRollingSize, len(matches)))
+	return sendTelegramPhotoFile(bot.apiBase, req.ChatID, "chart.png", data, caption, "HTML", nil)
+}
+
+func handleHeroCommand(bot *telegramBot, req commandRequest) error {
+	heroID, heroName, err := resolveHero(bot.heroes, req.Args.Text)
+	if err != nil {
+		return err
+	}
+	table, err := buildHeroTable(req.Locale, bot.accountStore.Get(), heroID)
+	if err != nil {
+		return err
+	}
+	return bot.sendTable(req.ChatID, req.Locale.T("hero.header", escapeHTML(heroName)), table)
+}
+
+func handleHeroesCommand(bot *telegramBot, req commandRequest) error {
+	player, err := resolveTrackedPlayer(loadTrackedPlayers(bot.accountStore.Get()), req.Args.Text)
+	if err != nil {
+		return err
+	}
+	table, err := buildPlayerHeroesTable(req.Locale, player.AccountID, bot.heroes, req.Args.Limit)
+	if err != nil {
+		return err
+	}
+	return bot.sendTable(req.ChatID, req.Locale.T("heroes.header", req.Args.Limit, escapeHTML(player.Name)), table)
+}
+
+func handleRolesCommand(bot *telegramBot, req commandRequest) error {
+	player, err := resolveTrackedPlayer(loadTrackedPlayers(bot.accountStore.Get()), req.Args.Text)
+	if err != nil {
+		return err
+	}
+	table, games, err := buildRolesTable(req.Locale, player.AccountID, req.Args.Limit)
+	if err != nil {
+		return err
+	}
+	return bot.sendTable(req.ChatID, req.Locale.T("roles.header", escapeHTML(player.Name), req.Locale.N(games, "games")), table)
+}
+
+func handleCompareCommand(bot *telegramBot, req commandRequest) error {
+	players := loadTrackedPlayers(bot.accountStore.Get())
+	a, err := resolveTrackedPlayer(players, req.Args.Targets[0])
+	if err != nil {
+		return err
+	}
+	b, err := resolveTrackedPlayer(players, req.Args.Targets[1])
+	if err != nil {
+		return err
+	}
+	if a.AccountID == b.AccountID {
+		return newLocalizedError("err.players_same")
+	}
+	table, err := buildCompareTable(req.Locale, a, b, req.Args.Limit, bot.heroes)
+	if err != nil {
+		return err
+	}
+	header := req.Locale.T("compare.header", escapeHTML(a.Name), escapeHTML(b.Name), req.Locale.N(req.Args.Limit, "games"))
+	return bot.sendTable(req.ChatID, header, table)
+}
+
+func handleMatchCommand(bot *telegramBot, req commandRequest) error {
+	return bot.sendScoreboard(req.ChatID, req.Args.MatchID)
+}
+
+func handleLastCommand(bot *telegramBot, req commandRequest) error {
+	player, err := resolveTrackedPlayer(loadTrackedPlayers(bot.accountStore.Get()), req.Args.Text)
+	if err != nil {
+		return err
+	}
+	matches, err := fetchRecentMatches(player.AccountID)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return newLocalizedError("err.no_recent", player.Name)
+	}
+	return bot.sendScoreboard(req.ChatID, matches[0].MatchID)
+}
+
+func (b *telegramBot) sendScoreboard(chatID int64, matchID int64) error {
+	details, err := fetchCachedMatchDetails(matchID)
+	if err != nil {
+		return err
+	}
+	if len(details.Players) == 0 {
+		return newLocalizedError("err.match_not_found", matchID)
+	}
+	loc := localeFor(chatID)
+	table := formatMatchScoreboard(loc, details, b.accountStore.Get(), b.heroes)
+	return b.sendTable(chatID, formatMatchScoreboardHeader(loc, details), table)
+}
+
+func handleScheduleCommand(bot *telegramBot, req commandRequest) error {
+	item := req.Args.Schedule
+	cmd, ok := bot.commands.Lookup(item.Command)
+	if !ok || cmd.Hidden {
+		return newLocalizedError("err.command_not_found", item.Command)
+	}
+	if !cmd.Schedulable {
+		return newLocalizedError("err.command_unschedulable", cmd.Name)
+	}
+	if _, err := cmd.Args(item.Args); err != nil {
+		return err
+	}
+	item.ChatID = req.ChatID
+	item.CreatedBy = req.UserID
+	item, err := bot.schedules.Add(item)
+	if err != nil {
+		return err
+	}
+	next, err := item.Next(time.Now())
+	if err != nil {
+		return err
+	}
+	loc, _ := item.Location()
+	text := req.Locale.T("schedule.added", item.Describe(), next.In(loc).Format("2006-01-02 15:04"))
+	return sendTelegramMessage(bot.apiBase, req.ChatID, text, "", nil)
+}
+
+func handleSchedulesCommand(bot *telegramBot, req commandRequest) error {
+	items := bot.schedules.List(req.ChatID)
+	if len(items) == 0 {
+		return sendTelegramMessage(bot.apiBase, req.ChatID, req.Locale.T("schedule.none"), "", nil)
+	}
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, item.Describe())
+	}
+	return sendTelegramMessage(bot.apiBase, req.ChatID, strings.Join(lines, "\n"), "", nil)
+}
+
+func handleUnscheduleCommand(bot *telegramBot, req commandRequest) error {
+	removed, err := bot.schedules.Remove(req.ChatID, req.Args.Limit)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return newLocalizedError("err.schedule_not_found", req.Args.Limit)
+	}
+	return sendTelegramMessage(bot.apiBase, req.ChatID, req.Locale.T("schedule.removed", req.Args.Limit), "", nil)
+}
+
+func handleChatIDCommand(bot *telegramBot, req commandRequest) error {
+	return sendTelegramMessage(bot.apiBase, req.ChatID, fmt.Sprintf("chat_id: %d", req.ChatID), "", nil)
+}
+
+func handleHelpCommand(bot *telegramBot, req commandRequest) error {
+	return sendTelegramMessage(bot.apiBase, req.ChatID, bot.commands.Help(req.Locale, bot.isAdmin(req)), "HTML", nil)
+}
+
+func handleTestCommand(bot *telegramBot, req commandRequest) error {
+	msg, err := buildTestMatchSummary(bot.accountStore.Get(), bot.heroes)
+	if err != nil {
+		return err
+	}
+	_, err = sendMatchNotification(bot.apiBase, req.ChatID, req.Locale, msg)
+	return err
+}
+
+func handleReloadCommand(bot *telegramBot, req commandRequest) error {
+	diff, err := bot.accountStore.Reload()
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+	text := formatRosterDiff(req.Locale, bot.accountStore.Source(), len(bot.accountStore.Get()), diff, rosterPlayerName)
+	if diff.Empty() {
+		text += req.Locale.T("reload.unchanged") + "\n"
+	}
+	return sendTelegramMessage(bot.apiBase, req.ChatID, text, "", nil)
+}
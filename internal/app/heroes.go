@@ -0,0 +1,154 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type heroPlayerStats struct {
+	Name    string
+	Games   int
+	Wins    int
+	Kills   float64
+	Deaths  float64
+	Assists float64
+}
+
+// resolveHero ищет героя по имени: точное совпадение, префикс, подстрока,
+// инициалы ("am" — Anti-Mage) и опечатки.
+func resolveHero(heroes map[int]string, query string) (int, string, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0, "", newLocalizedError("err.hero_required")
+	}
+	bestID, bestScore := 0, 0
+	for id, name := range heroes {
+		lower := strings.ToLower(name)
+		score, ok := fuzzyScore(lower, query)
+		if !ok && heroInitials(lower) == query {
+			score, ok = 2, true
+		}
+		if !ok {
+			continue
+		}
+		if bestID == 0 || score < bestScore || (score == bestScore && name < heroes[bestID]) {
+			bestID, bestScore = id, score
+		}
+	}
+	if bestID == 0 {
+		return 0, "", newLocalizedError("err.hero_not_found", query)
+	}
+	return bestID, heroes[bestID], nil
+}
+
+func heroInitials(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	})
+	if len(words) < 2 {
+		return ""
+	}
+	var builder strings.Builder
+	for _, word := range words {
+		builder.WriteRune([]rune(word)[0])
+	}
+	return builder.String()
+}
+
+func calcHeroPlayerStats(name string, entries []playerHeroEntry, matches []recentMatch) heroPlayerStats {
+	stats := heroPlayerStats{Name: name}
+	for _, entry := range entries {
+		stats.Games += entry.Games
+		stats.Wins += entry.Win
+	}
+	if len(matches) == 0 {
+		return stats
+	}
+	for _, m := range matches {
+		stats.Kills += float64(m.Kills)
+		stats.Deaths += float64(m.Deaths)
+		stats.Assists += float64(m.Assists)
+	}
+	n := float64(len(matches))
+	stats.Kills /= n
+	stats.Deaths /= n
+	stats.Assists /= n
+	return stats
+}
+
+func buildHeroTable(loc locale, accountIDs []int64, heroID int) (string, error) {
+	var rows []heroPlayerStats
+	for _, accountID := range accountIDs {
+		player, err := fetchCachedPlayerProfile(accountID)
+		if err != nil {
+			return "", err
+		}
+		entries, err := fetchPlayerHeroes(accountID, heroID)
+		if err != nil {
+			return "", err
+		}
+		matches, err := fetchPlayerHeroMatches(accountID, heroID)
+		if err != nil {
+			return "", err
+		}
+		rows = append(rows, calcHeroPlayerStats(displayName(accountID, player.PersonaName), entries, matches))
+	}
+	return formatHeroTable(loc, rows), nil
+}
+
+func formatHeroTable(loc locale, rows []heroPlayerStats) string {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Games > rows[j].Games
+	})
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%-16s  %-5s  %-9s  %-14s\n", loc.T("hero.player"), loc.T("hero.games"), "Winrate", loc.T("hero.kda")))
+	for _, row := range rows {
+		if row.Games == 0 {
+			builder.WriteString(fmt.Sprintf("%-16s  %-5d  %9s  %-14s\n", trimTo(row.Name, 16), 0, "-", "-"))
+			continue
+		}
+		winrate := float64(row.Wins) * 100 / float64(row.Games)
+		kda := fmt.Sprintf("%.1f/%.1f/%.1f", row.Kills, row.Deaths, row.Assists)
+		builder.WriteString(fmt.Sprintf("%-16s  %-5d  %8.1f%%  %-14s\n", trimTo(row.Name, 16), row.Games, winrate, kda))
+	}
+	return builder.String()
+}
+
+func buildPlayerHeroesTable(loc locale, accountID int64, heroes map[int]string, top int) (string, error) {
+	entries, err := fetchPlayerHeroes(accountID, 0)
+	if err != nil {
+		return "", err
+	}
+	return formatPlayerHeroesTable(loc, entries, heroes, top), nil
+}
+
+func formatPlayerHeroesTable(loc locale, entries []playerHeroEntry, heroes map[int]string, top int) string {
+	played := make([]playerHeroEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Games > 0 {
+			played = append(played, entry)
+		}
+	}
+	sort.SliceStable(played, func(i, j int) bool {
+		return played[i].Games > played[j].Games
+	})
+	if len(played) > top {
+		played = played[:top]
+	}
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%-3s  %-14s  %-5s  %-9s  %-10s\n", "№", loc.T("heroes.hero"), loc.T("heroes.games"), "Winrate", loc.T("heroes.last")))
+	for i, entry := range played {
+		heroName := heroes[entry.HeroID]
+		if heroName == "" {
+			heroName = fmt.Sprintf("Hero #%d", entry.HeroID)
+		}
+		winrate := float64(entry.Win) * 100 / float64(entry.Games)
+		last := "-"
+		if entry.LastPlayed > 0 {
+			last = loc.Time(entry.LastPlayed).Format("2006-01-02")
+		}
+		builder.WriteString(fmt.Sprintf("%-3d  %-14s  %-5d  %8.1f%%  %-10s\n", i+1, trimTo(heroName, 14), entry.Games, winrate, last))
+	}
+	return builder.String()
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveHero(t *testing.T) {
+	heroes := map[int]string{1: "Anti-Mage", 2: "Axe", 44: "Phantom Assassin", 12: "Phantom Lancer"}
+	cases := map[string]int{
+		"axe":       2,
+		"AM":        1,
+		"pa":        44,
+		"phantom l": 12,
+		"antimage":  1,
+	}
+	for query, want := range cases {
+		id, _, err := resolveHero(heroes, query)
+		if err != nil || id != want {
+			t.Fatalf("resolveHero(%q)=%d, %v; want %d", query, id, err, want)
+		}
+	}
+	if _, _, err := resolveHero(heroes, "zzzzzz"); err == nil {
+		t.Fatal("expected error for unknown hero")
+	}
+}
+
+func TestCalcHeroPlayerStats(t *testing.T) {
+	entries := []playerHeroEntry{{HeroID: 2, Games: 4, Win: 3}}
+	matches := []recentMatch{{Kills: 10, Deaths: 2, Assists: 4}, {Kills: 0, Deaths: 4, Assists: 6}}
+	stats := calcHeroPlayerStats("P", entries, matches)
+	if stats.Games != 4 || stats.Wins != 3 {
+		t.Fatalf("unexpected games/wins: %+v", stats)
+	}
+	if stats.Kills != 5 || stats.Deaths != 3 || stats.Assists != 5 {
+		t.Fatalf("unexpected averages: %+v", stats)
+	}
+}
+
+func TestFormatPlayerHeroesTable(t *testing.T) {
+	entries := []playerHeroEntry{
+		{HeroID: 1, Games: 2, Win: 1},
+		{HeroID: 2, Games: 10, Win: 7},
+		{HeroID: 3, Games: 0},
+	}
+	out := formatPlayerHeroesTable(newLocale(langRU), entries, map[int]string{1: "Anti-Mage", 2: "Axe"}, 5)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	// Заголовок + два сыгранных героя, Axe первым.
+	if len(lines) != 3 {
+		t.Fatalf("lines=%d, want 3: %q", len(lines), out)
+	}
+	if !strings.Contains(lines[1], "Axe") || !strings.Contains(lines[1], "70.0%") {
+		t.Fatalf("unexpected first row: %q", lines[1])
+	}
+}
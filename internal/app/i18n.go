@@ -0,0 +1,696 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	langRU = "ru"
+	langEN = "en"
+)
+
+// messageCatalogs — тексты бота по языкам. Формы множественного числа
+// разделяются "|": для ru — одна, две, пять (игра|игры|игр), для en — one|other.
+// Если ключа нет в каталоге языка, берётся русский текст.
+var messageCatalogs = map[string]map[string]string{
+	langRU: {
+		"lang.name":                 "русский",
+		"lang.current":              "Язык чата: %s. Доступны: %s",
+		"lang.set":                  "Язык чата изменён: %s",
+		"lang.unknown":              "неизвестный язык %q, доступны: %s",
+		"tz.current":                "Часовой пояс чата: %s. Сейчас %s",
+		"tz.set":                    "Часовой пояс чата изменён: %s. Сейчас %s",
+		"unknown":                   "неизвестный",
+		"no_data":                   "нет данных",
+		"error":                     "Ошибка: %s",
+		"admin_only":                "команда /%s доступна только администраторам",
+		"games":                     "%d игра|%d игры|%d игр",
+		"games.acc":                 "%d игру|%d игры|%d игр",
+		"games.gen":                 "%d игры|%d игр|%d игр",
+		"matches":                   "%d матч|%d матча|%d матчей",
+		"accounts":                  "%d аккаунт|%d аккаунта|%d аккаунтов",
+		"days":                      "%d день|%d дня|%d дней",
+		"filter.last":               "последние %s",
+		"filter.days":               "за %d дн.",
+		"filter.all":                "все игры",
+		"matches.title":             "Последние матчи (%s):",
+		"matches.date":              "Дата",
+		"matches.hero":              "Герой",
+		"matches.result":            "Итог",
+		"matches.duration":          "Длит.",
+		"friends.player":            "Игрок",
+		"friends.friend":            "Лучший друг",
+		"friends.games":             "Игр",
+		"details.win":               "✅ Победа",
+		"details.loss":              "❌ Поражение",
+		"details.player":            "Игрок",
+		"details.hero":              "Герой",
+		"details.duration":          "Длительность",
+		"details.score":             "Счёт",
+		"details.items":             "Предметы",
+		"button.details":            "Подробнее",
+		"button.collapse":           "Свернуть",
+		"notice.match":              "Загружаю детали матча",
+		"notice.rating":             "Обновляю рейтинг",
+		"help.title":                "Команды бота",
+		"stat.header":               "<b>Последние матчи (%s)</b>\n<b>Winrate (%s): %.1f%% за %s</b>\n<b>✅ победа, ❌ поражение</b>\n",
+		"friends.header":            "<b>Лучшие напарники по Winrate (%s)</b>\n",
+		"synergy.header":            "<b>Синергия пар (%s, от %s)</b>\n",
+		"chart.caption":             "<b>%s — последние %s</b>\nСверху вниз: винрейт (скользящее окно %d), K/D/A (🟩 🟥 🟦), GPM",
+		"hero.header":               "<b>Статистика на герое %s</b>\n",
+		"heroes.header":             "<b>Топ-%d героев (%s)</b>\n",
+		"compare.header":            "<b>%s vs %s (последние %s)</b>\n",
+		"schedule.added":            "Расписание добавлено: %s\nСледующий запуск: %s",
+		"schedule.none":             "Расписаний нет",
+		"schedule.removed":          "Расписание #%d удалено",
+		"alias.set":                 "Игрок %d теперь: %s",
+		"alias.none":                "Alias не заданы",
+		"err.tz":                    "неизвестный часовой пояс %q, нужен формат Area/City, например Europe/Moscow",
+		"schedule.daily":            "ежедневно",
+		"schedule.weekly":           "еженедельно, %s",
+		"weekday.0":                 "вс",
+		"weekday.1":                 "пн",
+		"weekday.2":                 "вт",
+		"weekday.3":                 "ср",
+		"weekday.4":                 "чт",
+		"weekday.5":                 "пт",
+		"weekday.6":                 "сб",
+		"reload.unchanged":          "Изменений нет",
+		"roster.updated":            "Список аккаунтов обновлён из %s: %s",
+		"roster.alias":              "alias %q → %q",
+		"roster.notify_on":          "уведомления включены",
+		"roster.notify_off":         "уведомления выключены",
+		"rating.title":              "Рейтинг по %s (%s)",
+		"rating.player":             "Игрок",
+		"rating.games":              "Игр",
+		"metric.winrate":            "Winrate",
+		"metric.kda":                "KDA",
+		"metric.rank":               "Ранг",
+		"metric.games":              "Игр",
+		"metric.gpm":                "GPM",
+		"metric.streak":             "Серия",
+		"metric.winrate.button":     "Winrate",
+		"metric.kda.button":         "KDA",
+		"metric.rank.button":        "Ранг",
+		"metric.games.button":       "Игры",
+		"metric.gpm.button":         "GPM",
+		"metric.streak.button":      "Серия",
+		"inline.title":              "<b>Последние матчи (%s)</b>",
+		"inline.winrate":            "<b>Winrate (за %s):</b> <code>%.1f%%</code>",
+		"inline.last":               "<b>Последние %d:</b> %s",
+		"inline.wins":               "<b>Побед/поражений:</b> <code>%d/%d</code>",
+		"inline.table":              "%s — последние матчи",
+		"inline.table_desc":         "Таблица последних матчей: %d",
+		"inline.card":               "%s — winrate %.1f%%",
+		"inline.card_desc":          "Карточка винрейта за %s",
+		"roles.header":              "<b>Роли и линии: %s</b>\nВсего — за всё время по OpenDota, последние — %s\n",
+		"roles.role":                "Роль",
+		"roles.total":               "Всего",
+		"roles.recent":              "Посл.",
+		"role.0":                    "Нет данных",
+		"role.1":                    "Лёгкая",
+		"role.2":                    "Мид",
+		"role.3":                    "Сложная",
+		"role.4":                    "Лес",
+		"role.5":                    "Роум",
+		"backfill.progress":         "Загрузка истории матчей: аккаунт %d из %d (%d), новых — %s",
+		"backfill.done":             "История матчей загружена. Аккаунтов: %d, новых — %s",
+		"backfill.failed":           "Загрузка истории прервана: %s\nПовторный запуск продолжит с того же места.",
+		"usage.backfill":            "используй /backfill или /backfill restart",
+		"err.backfill_running":      "загрузка истории уже идёт",
+		"games.ins":                 "%d игрой|%d играми|%d играми",
+		"deaths":                    "%d смерть|%d смерти|%d смертей",
+		"err.player_required":       "укажи игрока",
+		"err.player_not_found":      "игрок %q не найден среди отслеживаемых",
+		"err.players_same":          "нужно указать двух разных игроков",
+		"err.no_recent":             "у игрока %s нет недавних матчей",
+		"err.hero_required":         "укажи героя",
+		"err.hero_not_found":        "герой %q не найден",
+		"err.match_not_found":       "матч %d не найден",
+		"err.number_required":       "укажи номер",
+		"err.bad_number":            "некорректный номер: %s",
+		"err.limit_range":           "число должно быть от %d до %d",
+		"err.games_range":           "число игр должно быть от 1 до %d",
+		"err.min_games":             "некорректный порог игр: %s",
+		"err.bad_match_id":          "некорректный match_id: %s",
+		"err.queue_set":             "режим уже задан: %s",
+		"err.bad_filter":            "не понимаю %q: используй период (7d, 2w, 3m), режим (ranked, normal, turbo, allpick) или число игр",
+		"err.chart_matches":         "недостаточно матчей для графика",
+		"err.export_format":         "неизвестный формат: %s",
+		"err.export_report":         "неизвестный отчёт: %s",
+		"err.alias_long":            "имя длиннее 32 символов",
+		"err.command_not_found":     "команда /%s не найдена",
+		"err.command_unschedulable": "команду /%s нельзя поставить в расписание",
+		"err.schedule_not_found":    "расписание #%d не найдено в этом чате",
+		"err.schedule_command":      "расписание #%d: команда /%s не найдена",
+		"err.weekday":               "неизвестный день недели: %s",
+		"err.timezone":              "неизвестный часовой пояс: %s",
+		"err.clock":                 "время должно быть в формате HH:MM: %s",
+		"err.hour":                  "некорректный час: %s",
+		"err.minute":                "некорректные минуты: %s",
+		"err.test_no_accounts":      "нет аккаунтов для тестового сообщения",
+		"err.test_no_matches":       "не найдено ни одного матча для тестового сообщения",
+		"err.player_not_in_match":   "игрок не найден в деталях матча",
+		"err.steamid_empty":         "пустой идентификатор",
+		"err.steamid_format":        "не похоже на account_id, SteamID или ссылку на профиль: %q",
+		"err.steamid_range":         "некорректный account_id после преобразования: %d",
+		"err.steamid2":              "некорректный SteamID2 %q, ожидалось STEAM_0:Y:Z",
+		"err.steamid3":              "некорректный SteamID3 %q, ожидалось [U:1:N]",
+		"err.steamid_url":           "некорректная ссылка %q",
+		"err.steamid_vanity":        "ссылки вида steamcommunity.com/id/<имя> не поддерживаются, нужна /profiles/<SteamID64>: %q",
+		"err.steamid_profiles":      "в ссылке нет /profiles/<SteamID64>: %q",
+		"err.steamid_host":          "неизвестный сайт в ссылке %q",
+		"err.steamid_section":       "в ссылке нет /%s/<account_id>: %q",
+		"err.steamid_number":        "в ссылке %q нет числового ID",
+		"err.account_duplicate":     "аккаунт %d уже указан в строке %d",
+		"err.accounts_empty":        "%s: нет ни одного аккаунта",
+		"usage.chart":               "используй /chart <игрок> [число игр]",
+		"usage.heroes":              "используй /heroes <игрок> [число героев]",
+		"usage.roles":               "используй /roles <игрок> [число игр]",
+		"usage.hero":                "используй /hero <герой>",
+		"usage.last":                "используй /last <игрок>",
+		"usage.compare":             "используй /compare <игрок A> <игрок B> [число игр]",
+		"usage.match":               "используй /match <match_id>",
+		"usage.alias":               "используй /alias <игрок> <имя> или /alias <игрок> -",
+		"usage.export":              "используй /export matches|rating|friends [csv|json|md] [фильтры]",
+		"usage.schedule":            "используй /schedule daily HH:MM <команда> [аргументы] или /schedule weekly <день> HH:MM <команда> [аргументы]",
+		"compare.games":             "Игр",
+		"compare.kda":               "K/D/A (ср.)",
+		"compare.without":           "WR без другого",
+		"compare.top":               "Топ герои",
+		"compare.period":            "Вместе, друг против друга и WR без другого — за %s:",
+		"compare.period.all":        "Вместе, друг против друга и WR без другого — за всё время:",
+		"compare.together":          "Вместе: %s, winrate %.1f%%",
+		"compare.together.none":     "Вместе: нет игр",
+		"compare.against":           "Друг против друга: %s, %s %d : %d %s",
+		"compare.against.none":      "Друг против друга: нет игр",
+		"synergy.player":            "Игрок",
+		"synergy.partner":           "Напарник",
+		"synergy.games":             "Игр",
+		"synergy.hidden":            "Скрыто пар с менее чем %s: %d",
+		"synergy.none":              "Нет общих игр",
+		"awards.header":             "<b>🏆 Награды (%s, %s)</b>\n",
+		"awards.none":               "Нет матчей для наград",
+		"awards.match":              "матч",
+		"award.damage":              "💥 Больше всего урона",
+		"award.deaths":              "🍗 Кормилец",
+		"award.gpm":                 "💰 Лучший GPM",
+		"award.healing":             "💚 Лучший хилер",
+		"award.duration":            "⏳ Самая долгая игра",
+		"award.comeback":            "🔄 Камбэк недели",
+		"award.comeback.value":      "отыграно %s золота",
+		"hero.player":               "Игрок",
+		"hero.games":                "Игр",
+		"hero.kda":                  "K/D/A (ср.)",
+		"heroes.hero":               "Герой",
+		"heroes.games":              "Игр",
+		"heroes.last":               "Последняя",
+		"scoreboard.title":          "<b>Матч %d</b>",
+		"scoreboard.mode":           "<b>Режим:</b> %s, %s",
+		"scoreboard.time":           "<b>Начало:</b> <code>%s</code>, <b>длительность:</b> <code>%s</code>",
+		"scoreboard.score":          "<b>Счёт:</b> <code>%d:%d</code>, <b>победа:</b> 🏆 %s",
+		"scoreboard.hero":           "Герой",
+		"scoreboard.damage":         "Урон",
+		"team.radiant":              "Radiant",
+		"team.dire":                 "Dire",
+		"scoreboard.text":           "Матч %d: %s, %s, длительность %s, счёт %d:%d, победа %s",
+		"report.account_id":         "Account",
+		"report.player":             "Игрок",
+		"report.match_id":           "Матч",
+		"report.start_time":         "Дата",
+		"report.hero":               "Герой",
+		"report.win":                "Победа",
+		"report.kills":              "K",
+		"report.deaths":             "D",
+		"report.assists":            "A",
+		"report.duration":           "Длит., с",
+		"report.place":              "№",
+		"report.games":              "Игр",
+		"report.wins":               "Побед",
+		"report.winrate":            "Winrate, %",
+		"report.kda":                "KDA",
+		"report.gpm":                "GPM",
+		"report.rank_tier":          "rank_tier",
+		"report.rank":               "Ранг",
+		"report.streak":             "Серия",
+		"report.friend_id":          "Account друга",
+		"report.friend":             "Лучший друг",
+	},
+	langEN: {
+		"lang.name":                 "English",
+		"lang.current":              "Chat language: %s. Available: %s",
+		"lang.set":                  "Chat language set: %s",
+		"lang.unknown":              "unknown language %q, available: %s",
+		"tz.current":                "Chat time zone: %s. It is %s now",
+		"tz.set":                    "Chat time zone set: %s. It is %s now",
+		"unknown":                   "unknown",
+		"no_data":                   "no data",
+		"error":                     "Error: %s",
+		"admin_only":                "/%s is available to admins only",
+		"games":                     "%d game|%d games",
+		"games.acc":                 "%d game|%d games",
+		"games.gen":                 "%d game|%d games",
+		"matches":                   "%d match|%d matches",
+		"accounts":                  "%d account|%d accounts",
+		"days":                      "%d day|%d days",
+		"filter.last":               "last %s",
+		"filter.days":               "last %d d",
+		"filter.all":                "all games",
+		"matches.title":             "Recent matches (%s):",
+		"matches.date":              "Date",
+		"matches.hero":              "Hero",
+		"matches.result":            "Res.",
+		"matches.duration":          "Time",
+		"friends.player":            "Player",
+		"friends.friend":            "Best friend",
+		"friends.games":             "Games",
+		"details.win":               "✅ Victory",
+		"details.loss":              "❌ Defeat",
+		"details.player":            "Player",
+		"details.hero":              "Hero",
+		"details.duration":          "Duration",
+		"details.score":             "Score",
+		"details.items":             "Items",
+		"button.details":            "Details",
+		"button.collapse":           "Collapse",
+		"notice.match":              "Loading match details",
+		"notice.rating":             "Updating rating",
+		"help.title":                "Bot commands",
+		"stat.header":               "<b>Recent matches (%s)</b>\n<b>Winrate (%s): %.1f%% over %s</b>\n<b>✅ win, ❌ loss</b>\n",
+		"friends.header":            "<b>Best teammates by winrate (%s)</b>\n",
+		"synergy.header":            "<b>Pair synergy (%s, at least %s)</b>\n",
+		"chart.caption":             "<b>%s — last %s</b>\nTop to bottom: winrate (rolling window %d), K/D/A (🟩 🟥 🟦), GPM",
+		"hero.header":               "<b>Stats on %s</b>\n",
+		"heroes.header":             "<b>Top %d heroes (%s)</b>\n",
+		"compare.header":            "<b>%s vs %s (last %s)</b>\n",
+		"schedule.added":            "Schedule added: %s\nNext run: %s",
+		"schedule.none":             "No schedules",
+		"schedule.removed":          "Schedule #%d removed",
+		"alias.set":                 "Player %d is now: %s",
+		"alias.none":                "No aliases set",
+		"err.tz":                    "unknown time zone %q, use Area/City, for example Europe/Moscow",
+		"schedule.daily":            "daily",
+		"schedule.weekly":           "weekly, %s",
+		"weekday.0":                 "Sun",
+		"weekday.1":                 "Mon",
+		"weekday.2":                 "Tue",
+		"weekday.3":                 "Wed",
+		"weekday.4":                 "Thu",
+		"weekday.5":                 "Fri",
+		"weekday.6":                 "Sat",
+		"reload.unchanged":          "No changes",
+		"roster.updated":            "Account list reloaded from %s: %s",
+		"roster.alias":              "alias %q → %q",
+		"roster.notify_on":          "notifications on",
+		"roster.notify_off":         "notifications off",
+		"rating.title":              "Rating by %s (%s)",
+		"rating.player":             "Player",
+		"rating.games":              "Games",
+		"metric.winrate":            "Winrate",
+		"metric.kda":                "KDA",
+		"metric.rank":               "Rank",
+		"metric.games":              "Games",
+		"metric.gpm":                "GPM",
+		"metric.streak":             "Streak",
+		"metric.winrate.button":     "Winrate",
+		"metric.kda.button":         "KDA",
+		"metric.rank.button":        "Rank",
+		"metric.games.button":       "Games",
+		"metric.gpm.button":         "GPM",
+		"metric.streak.button":      "Streak",
+		"inline.title":              "<b>Recent matches (%s)</b>",
+		"inline.winrate":            "<b>Winrate (over %s):</b> <code>%.1f%%</code>",
+		"inline.last":               "<b>Last %d:</b> %s",
+		"inline.wins":               "<b>Wins/losses:</b> <code>%d/%d</code>",
+		"inline.table":              "%s — recent matches",
+		"inline.table_desc":         "Table of recent matches: %d",
+		"inline.card":               "%s — winrate %.1f%%",
+		"inline.card_desc":          "Winrate card over %s",
+		"roles.header":              "<b>Roles and lanes: %s</b>\nTotal is all time from OpenDota, recent is the last %s\n",
+		"roles.role":                "Role",
+		"roles.total":               "Total",
+		"roles.recent":              "Recent",
+		"role.0":                    "Unknown",
+		"role.1":                    "Safe lane",
+		"role.2":                    "Mid",
+		"role.3":                    "Off lane",
+		"role.4":                    "Jungle",
+		"role.5":                    "Roaming",
+		"backfill.progress":         "Loading match history: account %d of %d (%d), new: %s",
+		"backfill.done":             "Match history loaded. Accounts: %d, new: %s",
+		"backfill.failed":           "Match history loading stopped: %s\nRun it again to continue from the same place.",
+		"usage.backfill":            "usage: /backfill or /backfill restart",
+		"err.backfill_running":      "match history is already loading",
+		"games.ins":                 "%d game|%d games",
+		"deaths":                    "%d death|%d deaths",
+		"err.player_required":       "specify a player",
+		"err.player_not_found":      "player %q is not tracked",
+		"err.players_same":          "specify two different players",
+		"err.no_recent":             "%s has no recent matches",
+		"err.hero_required":         "specify a hero",
+		"err.hero_not_found":        "hero %q not found",
+		"err.match_not_found":       "match %d not found",
+		"err.number_required":       "specify a number",
+		"err.bad_number":            "invalid number: %s",
+		"err.limit_range":           "the number must be between %d and %d",
+		"err.games_range":           "the number of games must be between 1 and %d",
+		"err.min_games":             "invalid minimum of games: %s",
+		"err.bad_match_id":          "invalid match_id: %s",
+		"err.queue_set":             "queue is already set: %s",
+		"err.bad_filter":            "cannot parse %q: use a period (7d, 2w, 3m), a queue (ranked, normal, turbo, allpick) or a number of games",
+		"err.chart_matches":         "not enough matches for a chart",
+		"err.export_format":         "unknown format: %s",
+		"err.export_report":         "unknown report: %s",
+		"err.alias_long":            "the name is longer than 32 characters",
+		"err.command_not_found":     "command /%s not found",
+		"err.command_unschedulable": "/%s cannot be scheduled",
+		"err.schedule_not_found":    "schedule #%d not found in this chat",
+		"err.schedule_command":      "schedule #%d: command /%s not found",
+		"err.weekday":               "unknown weekday: %s",
+		"err.timezone":              "unknown time zone: %s",
+		"err.clock":                 "time must be HH:MM: %s",
+		"err.hour":                  "invalid hour: %s",
+		"err.minute":                "invalid minutes: %s",
+		"err.test_no_accounts":      "no accounts for a test message",
+		"err.test_no_matches":       "no matches found for a test message",
+		"err.player_not_in_match":   "player not found in match details",
+		"err.steamid_empty":         "empty identifier",
+		"err.steamid_format":        "does not look like an account_id, SteamID or profile link: %q",
+		"err.steamid_range":         "invalid account_id after conversion: %d",
+		"err.steamid2":              "invalid SteamID2 %q, expected STEAM_0:Y:Z",
+		"err.steamid3":              "invalid SteamID3 %q, expected [U:1:N]",
+		"err.steamid_url":           "invalid link %q",
+		"err.steamid_vanity":        "steamcommunity.com/id/<name> links are not supported, use /profiles/<SteamID64>: %q",
+		"err.steamid_profiles":      "no /profiles/<SteamID64> in the link: %q",
+		"err.steamid_host":          "unknown site in the link %q",
+		"err.steamid_section":       "no /%s/<account_id> in the link: %q",
+		"err.steamid_number":        "no numeric ID in the link %q",
+		"err.account_duplicate":     "account %d is already listed on line %d",
+		"err.accounts_empty":        "%s: no accounts",
+		"usage.chart":               "usage: /chart <player> [games]",
+		"usage.heroes":              "usage: /heroes <player> [heroes]",
+		"usage.roles":               "usage: /roles <player> [games]",
+		"usage.hero":                "usage: /hero <hero>",
+		"usage.last":                "usage: /last <player>",
+		"usage.compare":             "usage: /compare <player A> <player B> [games]",
+		"usage.match":               "usage: /match <match_id>",
+		"usage.alias":               "usage: /alias <player> <name> or /alias <player> -",
+		"usage.export":              "usage: /export matches|rating|friends [csv|json|md] [filters]",
+		"usage.schedule":            "usage: /schedule daily HH:MM <command> [args] or /schedule weekly <day> HH:MM <command> [args]",
+		"compare.games":             "Games",
+		"compare.kda":               "K/D/A (avg)",
+		"compare.without":           "WR without other",
+		"compare.top":               "Top heroes",
+		"compare.period":            "Together, head to head and WR without other, last %s:",
+		"compare.period.all":        "Together, head to head and WR without other, all time:",
+		"compare.together":          "Together: %s, winrate %.1f%%",
+		"compare.together.none":     "Together: no games",
+		"compare.against":           "Head to head: %s, %s %d : %d %s",
+		"compare.against.none":      "Head to head: no games",
+		"synergy.player":            "Player",
+		"synergy.partner":           "Partner",
+		"synergy.games":             "Games",
+		"synergy.hidden":            "Pairs with fewer than %s hidden: %d",
+		"synergy.none":              "No shared games",
+		"awards.header":             "<b>🏆 Awards (%s, %s)</b>\n",
+		"awards.none":               "No matches for awards",
+		"awards.match":              "match",
+		"award.damage":              "💥 Most damage",
+		"award.deaths":              "🍗 Feeder",
+		"award.gpm":                 "💰 Best GPM",
+		"award.healing":             "💚 Best healer",
+		"award.duration":            "⏳ Longest game",
+		"award.comeback":            "🔄 Comeback of the week",
+		"award.comeback.value":      "%s gold recovered",
+		"hero.player":               "Player",
+		"hero.games":                "Games",
+		"hero.kda":                  "K/D/A (avg)",
+		"heroes.hero":               "Hero",
+		"heroes.games":              "Games",
+		"heroes.last":               "Last",
+		"scoreboard.title":          "<b>Match %d</b>",
+		"scoreboard.mode":           "<b>Mode:</b> %s, %s",
+		"scoreboard.time":           "<b>Start:</b> <code>%s</code>, <b>duration:</b> <code>%s</code>",
+		"scoreboard.score":          "<b>Score:</b> <code>%d:%d</code>, <b>winner:</b> 🏆 %s",
+		"scoreboard.hero":           "Hero",
+		"scoreboard.damage":         "Damage",
+		"team.radiant":              "Radiant",
+		"team.dire":                 "Dire",
+		"scoreboard.text":           "Match %d: %s, %s, duration %s, score %d:%d, winner %s",
+		"report.account_id":         "Account",
+		"report.player":             "Player",
+		"report.match_id":           "Match",
+		"report.start_time":         "Date",
+		"report.hero":               "Hero",
+		"report.win":                "Win",
+		"report.kills":              "K",
+		"report.deaths":             "D",
+		"report.assists":            "A",
+		"report.duration":           "Duration, s",
+		"report.place":              "#",
+		"report.games":              "Games",
+		"report.wins":               "Wins",
+		"report.winrate":            "Winrate, %",
+		"report.kda":                "KDA",
+		"report.gpm":                "GPM",
+		"report.rank_tier":          "rank_tier",
+		"report.rank":               "Rank",
+		"report.streak":             "Streak",
+		"report.friend_id":          "Friend account",
+		"report.friend":             "Best friend",
+	},
+}
+
+// commandDescriptions — описания команд для /help и setMyCommands на
+// других языках; русские берутся из botCommand.Description.
+var commandDescriptions = map[string]map[string]string{
+	langEN: {
+		"stat":       "recent matches of all players",
+		"rating":     "player rating by winrate, KDA, rank, GPM or streak",
+		"friends":    "best teammates by winrate",
+		"synergy":    "winrate of every pair of players",
+		"export":     "export a report as a file",
+		"awards":     "weekly awards from match details",
+		"chart":      "winrate, K/D/A and GPM charts of a player",
+		"hero":       "all players' stats on a hero",
+		"heroes":     "player's most played heroes",
+		"roles":      "player's roles and lanes",
+		"compare":    "compare two players",
+		"match":      "full match scoreboard",
+		"last":       "scoreboard of the player's last match",
+		"schedule":   "post a report on schedule",
+		"schedules":  "schedules of this chat",
+		"unschedule": "delete a schedule",
+		"alias":      "permanent player names",
+		"lang":       "chat language",
+		"tz":         "chat time zone",
+		"chatid":     "show chat_id",
+		"help":       "list of commands",
+		"test":       "test match notification",
+		"reload":     "reload the account list",
+		"backfill":   "load the full match history",
+	},
+}
+
+// commandUsages — аргументы команд в /help на других языках; русские
+// берутся из botCommand.Usage.
+var commandUsages = map[string]map[string]string{
+	langEN: {
+		"stat":       "[period] [mode] [games]",
+		"rating":     "[metric] [period] [mode] [games]",
+		"friends":    "[period] [mode] [games]",
+		"synergy":    "[filters] [wr|games] [min=N]",
+		"export":     "matches|rating|friends [csv|json|md] [filters]",
+		"awards":     "[period] [mode] [games]",
+		"chart":      "<player> [games]",
+		"hero":       "<hero>",
+		"heroes":     "<player> [heroes]",
+		"roles":      "<player> [games]",
+		"compare":    "<player A> <player B> [games]",
+		"match":      "<match_id>",
+		"last":       "<player>",
+		"schedule":   "daily|weekly <day> HH:MM [Area/City] <command> [args]",
+		"unschedule": "<number>",
+		"alias":      "[<player> <name>|-]",
+		"lang":       "[ru|en|-]",
+		"tz":         "[Area/City|-]",
+		"backfill":   "[restart]",
+	},
+}
+
+// locale — язык и часовой пояс, в которых бот отвечает в чате или консоли.
+// Нулевой tz означает часовой пояс из конфига.
+type locale struct {
+	lang string
+	tz   *time.Location
+}
+
+var defaults atomic.Pointer[locale]
+
+func init() {
+	defaults.Store(&locale{lang: langRU, tz: time.Local})
+}
+
+func newLocale(lang string) locale {
+	if _, ok := messageCatalogs[lang]; !ok {
+		lang = langRU
+	}
+	return locale{lang: lang}
+}
+
+// defaultLocale — язык и часовой пояс из конфига, ими пользуются консоль
+// и чаты без /lang и /tz.
+func defaultLocale() locale {
+	return *defaults.Load()
+}
+
+func setDefaultLocale(lang string, tz *time.Location) {
+	if tz == nil {
+		tz = time.Local
+	}
+	defaults.Store(&locale{lang: newLocale(lang).lang, tz: tz})
+}
+
+// In возвращает локаль с другим часовым поясом.
+func (l locale) In(tz *time.Location) locale {
+	l.tz = tz
+	return l
+}
+
+// Location — часовой пояс для дат в отчётах и расписаниях.
+func (l locale) Location() *time.Location {
+	if l.tz != nil {
+		return l.tz
+	}
+	return defaultLocale().tz
+}
+
+// Time переводит unix-время OpenDota в часовой пояс локали.
+func (l locale) Time(unix int64) time.Time {
+	return time.Unix(unix, 0).In(l.Location())
+}
+
+func supportedLangs() []string {
+	langs := make([]string, 0, len(messageCatalogs))
+	for lang := range messageCatalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// normalizeLang принимает "ru", "EN", "en-US" и т.п.
+func normalizeLang(value string) (string, bool) {
+	lang := strings.ToLower(strings.TrimSpace(value))
+	if base, _, ok := strings.Cut(lang, "-"); ok {
+		lang = base
+	}
+	_, ok := messageCatalogs[lang]
+	return lang, ok
+}
+
+// Lang возвращает код языка; у нулевой локали это ru.
+func (l locale) Lang() string {
+	if l.lang == "" {
+		return langRU
+	}
+	return l.lang
+}
+
+func (l locale) text(key string) string {
+	if text, ok := messageCatalogs[l.Lang()][key]; ok {
+		return text
+	}
+	if text, ok := messageCatalogs[langRU][key]; ok {
+		return text
+	}
+	return key
+}
+
+// T возвращает текст по ключу, подставляя args через fmt.Sprintf.
+func (l locale) T(key string, args ...any) string {
+	text := l.text(key)
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// N выбирает форму множественного числа для n: N(5, "games") — "5 игр".
+func (l locale) N(n int, key string) string {
+	forms := strings.Split(l.text(key), "|")
+	form := forms[len(forms)-1]
+	if index := pluralIndex(l.Lang(), n); index < len(forms) {
+		form = forms[index]
+	}
+	if strings.Contains(form, "%d") {
+		return fmt.Sprintf(form, n)
+	}
+	return form
+}
+
+// pluralIndex — номер формы: для ru 0 — 1, 21; 1 — 2–4, 22; 2 — 5–20, 11–14;
+// для en 0 — ровно один, 1 — остальные.
+func pluralIndex(lang string, n int) int {
+	if n < 0 {
+		n = -n
+	}
+	if lang != langRU {
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+	mod10, mod100 := n%10, n%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return 0
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return 1
+	}
+	return 2
+}
+
+// CommandDescription — описание команды на языке локали.
+func (l locale) CommandDescription(cmd botCommand) string {
+	if text, ok := commandDescriptions[l.Lang()][cmd.Name]; ok {
+		return text
+	}
+	return cmd.Description
+}
+
+// CommandUsage — аргументы команды для /help на языке локали.
+func (l locale) CommandUsage(cmd botCommand) string {
+	if text, ok := commandUsages[l.Lang()][cmd.Name]; ok {
+		return text
+	}
+	return cmd.Usage
+}
+
+// localizedError — ошибка с текстом из каталога сообщений. В чат она уходит
+// на языке чата, в лог и консоль — на языке из конфига.
+type localizedError struct {
+	key  string
+	args []any
+}
+
+func newLocalizedError(key string, args ...any) error {
+	return &localizedError{key: key, args: args}
+}
+
+func (e *localizedError) Error() string {
+	return defaultLocale().T(e.key, e.args...)
+}
+
+// ErrorText — текст ошибки для чата: ошибки из каталога переводятся на язык
+// локали, остальные, в том числе обёрнутые, выводятся как есть.
+func (l locale) ErrorText(err error) string {
+	if localized, ok := err.(*localizedError); ok {
+		return l.T(localized.key, localized.args...)
+	}
+	return err.Error()
+}
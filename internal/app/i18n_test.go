@@ -0,0 +1,181 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+// withChatPrefs подменяет глобальные настройки чатов на время теста.
+func withChatPrefs(t *testing.T) *chatSettingsStore {
+	t.Helper()
+	previous := chatPrefs
+	chatPrefs = newChatSettingsStore()
+	t.Cleanup(func() { chatPrefs = previous })
+	return chatPrefs
+}
+
+func TestLocalePlural(t *testing.T) {
+	ru, en := newLocale(langRU), newLocale(langEN)
+	cases := map[int]string{1: "1 игра", 2: "2 игры", 5: "5 игр", 11: "11 игр", 14: "14 игр", 21: "21 игра", 22: "22 игры", 111: "111 игр"}
+	for n, want := range cases {
+		if got := ru.N(n, "games"); got != want {
+			t.Fatalf("ru.N(%d)=%q, want %q", n, got, want)
+		}
+	}
+	if got := en.N(1, "games"); got != "1 game" {
+		t.Fatalf("en.N(1)=%q", got)
+	}
+	if got := en.N(21, "games"); got != "21 games" {
+		t.Fatalf("en.N(21)=%q", got)
+	}
+}
+
+func TestLocaleFallback(t *testing.T) {
+	// Ключ без перевода берётся из русского каталога, неизвестный — как есть.
+	messageCatalogs[langRU]["test.only_ru"] = "только ru"
+	t.Cleanup(func() { delete(messageCatalogs[langRU], "test.only_ru") })
+	if got := newLocale(langEN).T("test.only_ru"); got != "только ru" {
+		t.Fatalf("fallback=%q", got)
+	}
+	if got := newLocale(langEN).T("test.missing"); got != "test.missing" {
+		t.Fatalf("missing key=%q", got)
+	}
+	if got := (locale{}).N(3, "games"); got != "3 игры" {
+		t.Fatalf("zero locale=%q", got)
+	}
+}
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	for lang, catalog := range messageCatalogs {
+		for key := range messageCatalogs[langRU] {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("%s: нет перевода %q", lang, key)
+			}
+		}
+	}
+}
+
+func TestDescribeIn(t *testing.T) {
+	filter := matchFilter{Limit: 21, Days: 7, Queue: "turbo"}
+	if got := filter.DescribeIn(newLocale(langRU)); got != "последние 21 игра, за 7 дн., turbo" {
+		t.Fatalf("ru=%q", got)
+	}
+	if got := filter.DescribeIn(newLocale(langEN)); got != "last 21 games, last 7 d, turbo" {
+		t.Fatalf("en=%q", got)
+	}
+}
+
+func TestNormalizeLang(t *testing.T) {
+	for value, want := range map[string]string{"ru": langRU, "EN": langEN, "en-US": langEN} {
+		if got, ok := normalizeLang(value); !ok || got != want {
+			t.Fatalf("normalizeLang(%q)=%q, %v", value, got, ok)
+		}
+	}
+	if _, ok := normalizeLang("de"); ok {
+		t.Fatal("de не поддерживается")
+	}
+}
+
+func TestChatSettingsStoreLang(t *testing.T) {
+	prefs := withChatPrefs(t)
+	path := filepath.Join(t.TempDir(), "chats.json")
+	if err := prefs.Load(path); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := prefs.Update(-100, func(s *chatSettings) { s.Lang = langEN }); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	reloaded := newChatSettingsStore()
+	if err := reloaded.Load(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := reloaded.Get(-100).Lang; got != langEN {
+		t.Fatalf("lang=%q", got)
+	}
+	if got := localeFor(-100).Lang(); got != langEN {
+		t.Fatalf("localeFor(-100)=%q", got)
+	}
+	if got := localeFor(7).Lang(); got != defaultLocale().Lang() {
+		t.Fatalf("localeFor(7)=%q", got)
+	}
+	// Пустые настройки удаляют чат из файла.
+	if err := prefs.Update(-100, func(s *chatSettings) { s.Lang = "" }); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if len(prefs.chats) != 0 {
+		t.Fatalf("chats=%v", prefs.chats)
+	}
+}
+
+func TestHelpInEnglish(t *testing.T) {
+	help := defaultCommands().Help(newLocale(langEN), false)
+	if !strings.Contains(help, "Bot commands") || !strings.Contains(help, "chat language") {
+		t.Fatalf("help=%q", help)
+	}
+	// Ни описаний, ни аргументов по-русски, в том числе у команд админа.
+	admin := defaultCommands().Help(newLocale(langEN), true)
+	if strings.ContainsFunc(admin, func(r rune) bool { return unicode.Is(unicode.Cyrillic, r) }) {
+		t.Fatalf("help=%q", admin)
+	}
+	_, err := parseChartArgs(nil)
+	if got := newLocale(langEN).ErrorText(err); got != "usage: /chart <player> [games]" {
+		t.Fatalf("usage=%q", got)
+	}
+}
+
+func TestLocaleForTimezone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("tzdata is not available")
+	}
+	prefs := withChatPrefs(t)
+	if err := prefs.Load(filepath.Join(t.TempDir(), "chats.json")); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := prefs.Update(-100, func(s *chatSettings) { s.Timezone = "Europe/Moscow" }); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	loc := localeFor(-100)
+	if loc.Location().String() != moscow.String() {
+		t.Fatalf("location=%v", loc.Location())
+	}
+	// 2024-05-01 09:00 UTC — 12:00 по Москве.
+	if got := loc.Time(1714554000).Format("2006-01-02 15:04"); got != "2024-05-01 12:00" {
+		t.Fatalf("time=%q", got)
+	}
+	var out strings.Builder
+	writeMatches(&out, loc, []recentMatch{{StartTime: 1714554000, HeroID: 1}}, map[int]string{1: "Axe"}, "Alpha", false)
+	if !strings.Contains(out.String(), "2024-05-01 12:00") {
+		t.Fatalf("writeMatches ignores timezone: %q", out.String())
+	}
+	// Расписание без своего пояса следует поясу чата.
+	s := schedule{ChatID: -100, Kind: scheduleDaily, Hour: 10}
+	next, err := s.Next(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	if want := time.Date(2024, 5, 2, 7, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("next=%v, want %v", next, want)
+	}
+}
+
+func TestLocalizedError(t *testing.T) {
+	_, err := parseMatchFilter([]string{"abc"}, 20, 100)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	// В лог ошибка пишется на языке конфига, в чат — на языке чата.
+	if !strings.HasPrefix(err.Error(), "не понимаю") {
+		t.Fatalf("error=%q", err)
+	}
+	if got := newLocale(langEN).ErrorText(err); !strings.HasPrefix(got, `cannot parse "abc"`) {
+		t.Fatalf("en=%q", got)
+	}
+	if got := newLocale(langEN).ErrorText(errors.New("boom")); got != "boom" {
+		t.Fatalf("plain=%q", got)
+	}
+}
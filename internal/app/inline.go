@@ -0,0 +1,98 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// handleInlineQuery отвечает на языке клиента пользователя, если он есть в
+// каталоге, иначе на языке из конфига. Игроки, чьи матчи не загрузились,
+// пропускаются.
+func (b *telegramBot) handleInlineQuery(query *telegramInlineQuery) error {
+	loc := defaultLocale()
+	if query.From != nil {
+		if lang, ok := normalizeLang(query.From.LanguageCode); ok {
+			loc = newLocale(lang)
+		}
+	}
+	found := matchTrackedPlayers(loadTrackedPlayers(b.accountStore.Get()), query.Query)
+	if len(found) > inlineMaxPlayers {
+		found = found[:inlineMaxPlayers]
+	}
+	results := make([]map[string]any, 0, len(found)*2)
+	for _, player := range found {
+		matches, err := fetchCachedRecentMatches(player.AccountID)
+		if err != nil {
+			slog.Warn("inline matches fetch failed", "account_id", player.AccountID, "error", err)
+			continue
+		}
+		results = append(results, buildInlinePlayerResults(loc, player, matches, b.heroes)...)
+	}
+	payload := map[string]any{
+		"inline_query_id": query.ID,
+		"results":         results,
+		"cache_time":      inlineCacheTime,
+	}
+	return callTelegram(b.apiBase, "answerInlineQuery", payload, nil)
+}
+
+func buildInlinePlayerResults(loc locale, player trackedPlayer, matches []recentMatch, heroes map[int]string) []map[string]any {
+	winrate, games := calcWinrateWithCount(matches, 20)
+	recent := matches
+	if len(recent) > 10 {
+		recent = recent[:10]
+	}
+	name := escapeHTML(player.Name)
+	table := buildPlayerTable(loc, recent, heroes, player.Name)
+	tableText := loc.T("inline.title", name) + "\n<pre>" + escapeHTML(table) + "</pre>"
+
+	wins := 0
+	for _, m := range recent {
+		if matchWin(m) {
+			wins++
+		}
+	}
+	cardText := strings.Join([]string{
+		fmt.Sprintf("<b>%s</b>", name),
+		loc.T("inline.winrate", loc.N(games, "games.acc"), winrate),
+		loc.T("inline.last", len(recent), formatResultStreak(recent)),
+		loc.T("inline.wins", wins, len(recent)-wins),
+		fmt.Sprintf("<a href=\"https://www.opendota.com/players/%d\">OpenDota</a>", player.AccountID),
+	}, "\n")
+
+	return []map[string]any{
+		{
+			"type":        "article",
+			"id":          fmt.Sprintf("table:%d", player.AccountID),
+			"title":       loc.T("inline.table", player.Name),
+			"description": loc.T("inline.table_desc", len(recent)),
+			"input_message_content": map[string]any{
+				"message_text": tableText,
+				"parse_mode":   "HTML",
+			},
+		},
+		{
+			"type":        "article",
+			"id":          fmt.Sprintf("winrate:%d", player.AccountID),
+			"title":       loc.T("inline.card", player.Name, winrate),
+			"description": loc.T("inline.card_desc", loc.N(games, "games.acc")),
+			"input_message_content": map[string]any{
+				"message_text": cardText,
+				"parse_mode":   "HTML",
+			},
+		},
+	}
+}
+
+func formatResultStreak(matches []recentMatch) string {
+	var builder strings.Builder
+	for _, m := range matches {
+		if matchWin(m) {
+			builder.WriteString("✅")
+		} else {
+			builder.WriteString("❌")
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildInlinePlayerResults(t *testing.T) {
+	matches := []recentMatch{{HeroID: 1, Kills: 1, Deaths: 2, Assists: 3, RadiantWin: true}}
+	results := buildInlinePlayerResults(newLocale(langRU), trackedPlayer{AccountID: 7, Name: "<Nick>"}, matches, map[int]string{1: "Axe"})
+	if len(results) != 2 {
+		t.Fatalf("len=%d, want 2", len(results))
+	}
+	content := results[0]["input_message_content"].(map[string]any)
+	text := content["message_text"].(string)
+	if !strings.Contains(text, "&lt;Nick&gt;") || !strings.Contains(text, "Axe") {
+		t.Fatalf("unexpected table text: %q", text)
+	}
+	if results[0]["id"] == results[1]["id"] {
+		t.Fatal("result ids must be unique")
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+type logConfig struct {
+	Level  string
+	Format string
+}
+
+func parseLogLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("неизвестный уровень логов %q, ожидалось debug, info, warn или error", value)
+}
+
+// newLogger пишет в w текстом или JSON начиная с уровня cfg.Level.
+func newLogger(w io.Writer, cfg logConfig) (*slog.Logger, error) {
+	level, err := parseLogLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.Format) {
+	case "", logFormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case logFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	}
+	return nil, fmt.Errorf("неизвестный формат логов %q, ожидалось text или json", cfg.Format)
+}
+
+func setupLogging(cfg logConfig) error {
+	logger, err := newLogger(os.Stderr, cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, logConfig{Level: "warn", Format: "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Info("skipped")
+	logger.Warn("new match", "account_id", int64(42), "match_id", int64(7))
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("output %q is not a single JSON record: %v", buf.String(), err)
+	}
+	if record["msg"] != "new match" || record["account_id"] != float64(42) || record["level"] != "WARN" {
+		t.Fatalf("record=%v", record)
+	}
+}
+
+func TestNewLoggerRejectsUnknownSettings(t *testing.T) {
+	if _, err := newLogger(&bytes.Buffer{}, logConfig{Level: "loud"}); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+	if _, err := newLogger(&bytes.Buffer{}, logConfig{Format: "xml"}); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestLogHandled(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	logHandled("command", nil, time.Now(), "command", "stat", "chat_id", int64(-100))
+	logHandled("command", errors.New("boom"), time.Now(), "command", "rating")
+	out := buf.String()
+	for _, want := range []string{"level=INFO msg=command command=stat chat_id=-100 duration=", "level=WARN msg=\"command failed\" command=rating", "error=boom"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("log %q should contain %q", out, want)
+		}
+	}
+}
+
+func TestWithoutURL(t *testing.T) {
+	err := fmt.Errorf("telegram sendMessage: %w", withoutURL(&url.Error{
+		Op: "Post", URL: "https://api.telegram.org/bot123:secret/sendMessage", Err: io.EOF,
+	}))
+	if strings.Contains(err.Error(), "secret") || !errors.Is(err, io.EOF) {
+		t.Fatalf("err=%q", err)
+	}
+}
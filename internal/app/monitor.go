@@ -2,11 +2,13 @@ package app
 
 import (
 	"fmt"
-	"os"
+	"log/slog"
 	"time"
 )
 
-func monitorMatches(accountStore *accountIDStore, heroes map[int]string, notify func(matchNotification)) {
+// monitorMatches раз в interval проверяет новые матчи. Для аккаунтов с
+// notify = false матчи только запоминаются, уведомления не отправляются.
+func monitorMatches(accountStore *accountIDStore, heroes map[int]string, notify func(matchNotification), interval time.Duration) {
 	accountIDs := accountStore.Get()
 	lastMatch := make(map[int64]int64, len(accountIDs))
 	names := make(map[int64]string, len(accountIDs))
@@ -18,13 +20,13 @@ func monitorMatches(accountStore *accountIDStore, heroes map[int]string, notify
 	for _, accountID := range accountIDs {
 		player, err := fetchPlayerProfile(accountID)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "profile error: %s\n", err.Error())
+			slog.Warn("profile fetch failed", "account_id", accountID, "error", err)
 		} else {
-			names[accountID] = fallbackName(player.PersonaName)
+			names[accountID] = player.PersonaName
 		}
 		matches, err := fetchRecentMatches(accountID)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "matches error: %s\n", err.Error())
+			slog.Warn("recent matches fetch failed", "account_id", accountID, "error", err)
 			continue
 		}
 		if len(matches) > 0 {
@@ -32,22 +34,24 @@ func monitorMatches(accountStore *accountIDStore, heroes map[int]string, notify
 		}
 	}
 
-	ticker := time.NewTicker(5 * time.Minute)
+	slog.Info("monitor started", "accounts", len(accountIDs), "interval", interval)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	for range ticker.C {
 		accountIDs = accountStore.Get()
+		pruneMonitorState(accountIDs, lastMatch, names)
 		for _, accountID := range accountIDs {
 			if _, ok := names[accountID]; !ok {
 				player, err := fetchPlayerProfile(accountID)
 				if err != nil {
-					fmt.Fprintf(os.Stderr, "profile error: %s\n", err.Error())
+					slog.Warn("profile fetch failed", "account_id", accountID, "error", err)
 				} else {
-					names[accountID] = fallbackName(player.PersonaName)
+					names[accountID] = player.PersonaName
 				}
 			}
 			matches, err := fetchRecentMatches(accountID)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "matches error: %s\n", err.Error())
+				slog.Warn("recent matches fetch failed", "account_id", accountID, "error", err)
 				continue
 			}
 			if len(matches) == 0 {
@@ -68,14 +72,39 @@ func monitorMatches(accountStore *accountIDStore, heroes map[int]string, notify
 				}
 				newMatches = append(newMatches, m)
 			}
+			lastMatch[accountID] = matches[0].MatchID
+			notifyOn := accountStore.Account(accountID).Notify
 			for i := len(newMatches) - 1; i >= 0; i-- {
+				slog.Info("new match", "account_id", accountID, "match_id", newMatches[i].MatchID, "notify", notifyOn)
+				if !notifyOn {
+					continue
+				}
 				notify(matchNotification{
-					Text:      formatMatchSummary(names[accountID], newMatches[i], heroes),
+					Text:      formatMatchSummary(displayName(accountID, names[accountID]), newMatches[i], heroes),
 					MatchID:   newMatches[i].MatchID,
 					AccountID: accountID,
+					Match:     newMatches[i],
 				})
 			}
-			lastMatch[accountID] = matches[0].MatchID
+		}
+	}
+}
+
+// pruneMonitorState забывает аккаунты, удалённые из списка: если аккаунт
+// вернут, его матчи снова начнут отслеживаться с последнего, без старых имён.
+func pruneMonitorState(accountIDs []int64, lastMatch map[int64]int64, names map[int64]string) {
+	current := make(map[int64]struct{}, len(accountIDs))
+	for _, id := range accountIDs {
+		current[id] = struct{}{}
+	}
+	for id := range lastMatch {
+		if _, ok := current[id]; !ok {
+			delete(lastMatch, id)
+		}
+	}
+	for id := range names {
+		if _, ok := current[id]; !ok {
+			delete(names, id)
 		}
 	}
 }
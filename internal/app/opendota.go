@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"sort"
 	"strings"
@@ -12,6 +13,7 @@ import (
 
 type hero struct {
 	ID            int    `json:"id"`
+	Name          string `json:"name"`
 	LocalizedName string `json:"localized_name"`
 }
 
@@ -25,6 +27,13 @@ type recentMatch struct {
 	StartTime  int64 `json:"start_time"`
 	PlayerSlot int   `json:"player_slot"`
 	RadiantWin bool  `json:"radiant_win"`
+	GPM        int   `json:"gold_per_min"`
+	XPM        int   `json:"xp_per_min"`
+	GameMode   int   `json:"game_mode"`
+	LobbyType  int   `json:"lobby_type"`
+	// LaneRole и IsRoaming OpenDota заполняет только для разобранных матчей.
+	LaneRole  int  `json:"lane_role"`
+	IsRoaming bool `json:"is_roaming"`
 }
 
 type playerProfile struct {
@@ -32,11 +41,13 @@ type playerProfile struct {
 		PersonaName string `json:"personaname"`
 		AvatarFull  string `json:"avatarfull"`
 	} `json:"profile"`
+	RankTier int `json:"rank_tier"`
 }
 
 type playerProfileData struct {
 	PersonaName string
 	AvatarFull  string
+	RankTier    int
 }
 
 type peerEntry struct {
@@ -52,75 +63,107 @@ type playerMatch struct {
 	RadiantWin bool  `json:"radiant_win"`
 }
 
+type playerHeroEntry struct {
+	HeroID     int   `json:"hero_id"`
+	LastPlayed int64 `json:"last_played"`
+	Games      int   `json:"games"`
+	Win        int   `json:"win"`
+}
+
 type matchDetails struct {
-	MatchID      int64                `json:"match_id"`
-	Duration     int                  `json:"duration"`
-	StartTime    int64                `json:"start_time"`
-	GameMode     int                  `json:"game_mode"`
-	LobbyType    int                  `json:"lobby_type"`
-	RadiantWin   bool                 `json:"radiant_win"`
-	RadiantScore int                  `json:"radiant_score"`
-	DireScore    int                  `json:"dire_score"`
-	FirstBlood   int                  `json:"first_blood_time"`
-	LeagueName   string               `json:"league_name"`
-	Players      []matchDetailsPlayer `json:"players"`
+	MatchID        int64                `json:"match_id"`
+	Duration       int                  `json:"duration"`
+	StartTime      int64                `json:"start_time"`
+	GameMode       int                  `json:"game_mode"`
+	LobbyType      int                  `json:"lobby_type"`
+	RadiantWin     bool                 `json:"radiant_win"`
+	RadiantScore   int                  `json:"radiant_score"`
+	DireScore      int                  `json:"dire_score"`
+	FirstBlood     int                  `json:"first_blood_time"`
+	LeagueName     string               `json:"league_name"`
+	RadiantGoldAdv []int                `json:"radiant_gold_adv"`
+	Players        []matchDetailsPlayer `json:"players"`
 }
 
 type matchDetailsPlayer struct {
-	AccountID    int64  `json:"account_id"`
-	PersonaName  string `json:"personaname"`
-	HeroID       int    `json:"hero_id"`
-	PlayerSlot   int    `json:"player_slot"`
-	Kills        int    `json:"kills"`
-	Deaths       int    `json:"deaths"`
-	Assists      int    `json:"assists"`
-	Level        int    `json:"level"`
-	GPM          int    `json:"gold_per_min"`
-	XPM          int    `json:"xp_per_min"`
-	LastHits     int    `json:"last_hits"`
-	Denies       int    `json:"denies"`
-	HeroDamage   int    `json:"hero_damage"`
-	TowerDamage  int    `json:"tower_damage"`
-	HeroHealing  int    `json:"hero_healing"`
-	NetWorth     int    `json:"net_worth"`
-	Item0        int    `json:"item_0"`
-	Item1        int    `json:"item_1"`
-	Item2        int    `json:"item_2"`
-	Item3        int    `json:"item_3"`
-	Item4        int    `json:"item_4"`
-	Item5        int    `json:"item_5"`
-	Backpack0    int    `json:"backpack_0"`
-	Backpack1    int    `json:"backpack_1"`
-	Backpack2    int    `json:"backpack_2"`
-	NeutralItem  int    `json:"item_neutral"`
+	AccountID   int64  `json:"account_id"`
+	PersonaName string `json:"personaname"`
+	HeroID      int    `json:"hero_id"`
+	PlayerSlot  int    `json:"player_slot"`
+	Kills       int    `json:"kills"`
+	Deaths      int    `json:"deaths"`
+	Assists     int    `json:"assists"`
+	Level       int    `json:"level"`
+	GPM         int    `json:"gold_per_min"`
+	XPM         int    `json:"xp_per_min"`
+	LastHits    int    `json:"last_hits"`
+	Denies      int    `json:"denies"`
+	HeroDamage  int    `json:"hero_damage"`
+	TowerDamage int    `json:"tower_damage"`
+	HeroHealing int    `json:"hero_healing"`
+	NetWorth    int    `json:"net_worth"`
+	Item0       int    `json:"item_0"`
+	Item1       int    `json:"item_1"`
+	Item2       int    `json:"item_2"`
+	Item3       int    `json:"item_3"`
+	Item4       int    `json:"item_4"`
+	Item5       int    `json:"item_5"`
+	Backpack0   int    `json:"backpack_0"`
+	Backpack1   int    `json:"backpack_1"`
+	Backpack2   int    `json:"backpack_2"`
+	NeutralItem int    `json:"item_neutral"`
+	LaneRole    int    `json:"lane_role"`
+	IsRoaming   bool   `json:"is_roaming"`
+}
+
+// playerCounts — ответ /players/{id}/counts; из него нужны только линии.
+type playerCounts struct {
+	LaneRole map[string]playerCountEntry `json:"lane_role"`
+}
+
+type playerCountEntry struct {
+	Games int `json:"games"`
+	Win   int `json:"win"`
 }
 
 type itemConstantsEntry struct {
-	ID   int    `json:"id"`
+	ID    int    `json:"id"`
 	DName string `json:"dname"`
 }
 
 type rateLimiter struct {
-	tick <-chan time.Time
+	ticker *time.Ticker
 }
 
 func newRateLimiter(max int, per time.Duration) *rateLimiter {
 	if max <= 0 {
 		return nil
 	}
+	return &rateLimiter{ticker: time.NewTicker(rateInterval(max, per))}
+}
+
+// SetRate меняет лимит на месте: тикер остаётся тем же, поэтому повторный
+// applyConfig не копит тикеры, а ждущие в Wait продолжают получать тики.
+func (l *rateLimiter) SetRate(max int, per time.Duration) {
+	if l == nil || max <= 0 {
+		return
+	}
+	l.ticker.Reset(rateInterval(max, per))
+}
+
+func rateInterval(max int, per time.Duration) time.Duration {
 	interval := per / time.Duration(max)
 	if interval <= 0 {
 		interval = time.Second
 	}
-	ticker := time.NewTicker(interval)
-	return &rateLimiter{tick: ticker.C}
+	return interval
 }
 
 func (l *rateLimiter) Wait() {
 	if l == nil {
 		return
 	}
-	<-l.tick
+	<-l.ticker.C
 }
 
 func fetchHeroes() (map[int]string, error) {
@@ -138,21 +181,109 @@ func fetchHeroes() (map[int]string, error) {
 	return result, nil
 }
 
+// fetchHeroSlugs возвращает внутренние имена героев без префикса
+// npc_dota_hero_, по ним называются портреты в images/heroes.
+func fetchHeroSlugs() (map[int]string, error) {
+	var heroes []hero
+	if err := getOpendotaJSON(baseURL+heroesURL, &heroes); err != nil {
+		return nil, err
+	}
+	result := make(map[int]string, len(heroes))
+	for _, h := range heroes {
+		slug := strings.TrimPrefix(h.Name, "npc_dota_hero_")
+		if slug == "" {
+			continue
+		}
+		result[h.ID] = slug
+	}
+	return result, nil
+}
+
 func fetchRecentMatches(accountID int64) ([]recentMatch, error) {
 	var matches []recentMatch
 	url := fmt.Sprintf(baseURL+recentMatchesURL, accountID)
 	if err := getOpendotaJSON(url, &matches); err != nil {
-		return nil, err
+		return localMatchesOr(accountID, matchFilter{Limit: recentMatchesCount}, err)
 	}
+	matchHistory.PutRecent(accountID, matches)
 	return matches, nil
 }
 
+// fetchCachedRecentMatches — fetchRecentMatches для inline-режима, где запрос
+// приходит на каждое нажатие клавиши.
+func fetchCachedRecentMatches(accountID int64) ([]recentMatch, error) {
+	return recentCache.GetOrLoad(accountID, func() ([]recentMatch, error) {
+		return fetchRecentMatches(accountID)
+	})
+}
+
 func fetchPlayerMatches(accountID int64, limit int) ([]recentMatch, error) {
 	if limit <= 0 {
 		return []recentMatch{}, nil
 	}
+	return fetchFilteredMatches(accountID, matchFilter{Limit: limit})
+}
+
+func fetchFilteredMatches(accountID int64, filter matchFilter) ([]recentMatch, error) {
+	if local, ok := matchHistory.FreshMatches(accountID, filter, time.Now()); ok {
+		return local, nil
+	}
+	var matches []recentMatch
+	url := fmt.Sprintf("%s"+playerMatchesURL+"?%s", baseURL, accountID, strings.TrimPrefix(filter.Query(), "&"))
+	if err := getOpendotaJSON(url, &matches); err != nil {
+		return localMatchesOr(accountID, filter, err)
+	}
+	matchHistory.PutRows(accountID, matches)
+	return matches, nil
+}
+
+// fetchPlayerMatchStats запрашивает матчи с GPM/XPM, которых нет в ответе по умолчанию.
+func fetchPlayerMatchStats(accountID int64, limit int) ([]recentMatch, error) {
+	if limit <= 0 {
+		return []recentMatch{}, nil
+	}
+	if local, ok := matchHistory.FreshMatches(accountID, matchFilter{Limit: limit}, time.Now()); ok {
+		return local, nil
+	}
+	var matches []recentMatch
+	url := fmt.Sprintf("%s"+playerMatchesURL+"?limit=%d%s", baseURL, accountID, limit, matchStatsProjection)
+	if err := getOpendotaJSON(url, &matches); err != nil {
+		return localMatchesOr(accountID, matchFilter{Limit: limit}, err)
+	}
+	matchHistory.PutRows(accountID, matches)
+	return matches, nil
+}
+
+// fetchFilteredMatchStats — fetchFilteredMatches с K/D/A, GPM и XPM.
+func fetchFilteredMatchStats(accountID int64, filter matchFilter) ([]recentMatch, error) {
+	if local, ok := matchHistory.FreshMatches(accountID, filter, time.Now()); ok {
+		return local, nil
+	}
+	var matches []recentMatch
+	url := fmt.Sprintf("%s"+playerMatchesURL+"?%s%s", baseURL, accountID, strings.TrimPrefix(filter.Query(), "&"), matchStatsProjection)
+	if err := getOpendotaJSON(url, &matches); err != nil {
+		return localMatchesOr(accountID, filter, err)
+	}
+	matchHistory.PutRows(accountID, matches)
+	return matches, nil
+}
+
+// fetchPlayerHeroes возвращает статистику по героям; heroID > 0 оставляет одного героя.
+func fetchPlayerHeroes(accountID int64, heroID int) ([]playerHeroEntry, error) {
+	var entries []playerHeroEntry
+	url := fmt.Sprintf(baseURL+playerHeroesURL, accountID)
+	if heroID > 0 {
+		url += fmt.Sprintf("?hero_id=%d", heroID)
+	}
+	if err := getOpendotaJSON(url, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func fetchPlayerHeroMatches(accountID int64, heroID int) ([]recentMatch, error) {
 	var matches []recentMatch
-	url := fmt.Sprintf("%s"+playerMatchesURL+"?limit=%d", baseURL, accountID, limit)
+	url := fmt.Sprintf("%s"+playerMatchesURL+"?hero_id=%d%s", baseURL, accountID, heroID, kdaProjection)
 	if err := getOpendotaJSON(url, &matches); err != nil {
 		return nil, err
 	}
@@ -163,12 +294,34 @@ func fetchPlayerProfile(accountID int64) (playerProfileData, error) {
 	var player playerProfile
 	url := fmt.Sprintf(baseURL+playerURL, accountID)
 	if err := getOpendotaJSON(url, &player); err != nil {
+		if local, ok := matchHistory.Player(accountID); ok {
+			slog.Warn("opendota unavailable, using local profile", "account_id", accountID, "error", err)
+			return local, nil
+		}
 		return playerProfileData{}, err
 	}
-	return playerProfileData{
+	profile := playerProfileData{
 		PersonaName: strings.TrimSpace(player.Profile.PersonaName),
 		AvatarFull:  strings.TrimSpace(player.Profile.AvatarFull),
-	}, nil
+		RankTier:    player.RankTier,
+	}
+	matchHistory.PutPlayer(accountID, profile)
+	return profile, nil
+}
+
+func fetchCachedPlayerProfile(accountID int64) (playerProfileData, error) {
+	return profileCache.GetOrLoad(accountID, func() (playerProfileData, error) {
+		return fetchPlayerProfile(accountID)
+	})
+}
+
+func fetchPlayerCounts(accountID int64) (playerCounts, error) {
+	var counts playerCounts
+	url := fmt.Sprintf(baseURL+playerCountsURL, accountID)
+	if err := getOpendotaJSON(url, &counts); err != nil {
+		return playerCounts{}, err
+	}
+	return counts, nil
 }
 
 func fetchPeers(accountID int64) ([]peerEntry, error) {
@@ -180,9 +333,34 @@ func fetchPeers(accountID int64) ([]peerEntry, error) {
 	return peers, nil
 }
 
-func fetchMatchesWith(accountID int64, includedAccountID int64, limit int) ([]playerMatch, error) {
+func fetchMatchesWith(accountID int64, includedAccountID int64, filter matchFilter) ([]playerMatch, error) {
+	if local, ok := matchHistory.FreshMatchesWith(accountID, includedAccountID, true, filter, time.Now()); ok {
+		return local, nil
+	}
 	var matches []playerMatch
-	url := fmt.Sprintf("%s"+playerMatchesURL+"?included_account_id=%d&limit=%d", baseURL, accountID, includedAccountID, limit)
+	url := fmt.Sprintf("%s"+playerMatchesURL+"?included_account_id=%d%s", baseURL, accountID, includedAccountID, filter.Query())
+	if err := getOpendotaJSON(url, &matches); err != nil {
+		return localMatchesWithOr(accountID, includedAccountID, true, filter, err)
+	}
+	return matches, nil
+}
+
+func fetchMatchesWithout(accountID int64, excludedAccountID int64, filter matchFilter) ([]playerMatch, error) {
+	if local, ok := matchHistory.FreshMatchesWith(accountID, excludedAccountID, false, filter, time.Now()); ok {
+		return local, nil
+	}
+	var matches []playerMatch
+	url := fmt.Sprintf("%s"+playerMatchesURL+"?excluded_account_id=%d%s", baseURL, accountID, excludedAccountID, filter.Query())
+	if err := getOpendotaJSON(url, &matches); err != nil {
+		return localMatchesWithOr(accountID, excludedAccountID, false, filter, err)
+	}
+	return matches, nil
+}
+
+// fetchMatchPage — страница всех матчей игрока от самого нового, для backfill.
+func fetchMatchPage(accountID int64, offset int) ([]recentMatch, error) {
+	var matches []recentMatch
+	url := fmt.Sprintf("%s"+playerMatchesURL+"?limit=%d&offset=%d%s", baseURL, accountID, backfillPageSize, offset, matchStatsProjection)
 	if err := getOpendotaJSON(url, &matches); err != nil {
 		return nil, err
 	}
@@ -193,11 +371,34 @@ func fetchMatchDetails(matchID int64) (matchDetails, error) {
 	var details matchDetails
 	url := fmt.Sprintf(baseURL+matchURL, matchID)
 	if err := getOpendotaJSON(url, &details); err != nil {
+		if local, ok := matchHistory.Match(matchID); ok {
+			slog.Warn("opendota unavailable, using local match", "match_id", matchID, "error", err)
+			return local, nil
+		}
 		return matchDetails{}, err
 	}
+	matchHistory.PutMatch(details)
 	return details, nil
 }
 
+// localMatchesOr отвечает из локальной истории, если OpenDota недоступен,
+// а об игроке что-то известно; иначе возвращает исходную ошибку.
+func localMatchesOr(accountID int64, filter matchFilter, err error) ([]recentMatch, error) {
+	if local, ok := matchHistory.Matches(accountID, filter, time.Now()); ok {
+		slog.Warn("opendota unavailable, using local history", "account_id", accountID, "matches", len(local), "error", err)
+		return local, nil
+	}
+	return nil, err
+}
+
+func localMatchesWithOr(accountID int64, otherID int64, with bool, filter matchFilter, err error) ([]playerMatch, error) {
+	if local, ok := matchHistory.MatchesWith(accountID, otherID, with, filter, time.Now()); ok {
+		slog.Warn("opendota unavailable, using local history", "account_id", accountID, "other_account_id", otherID, "matches", len(local), "error", err)
+		return local, nil
+	}
+	return nil, err
+}
+
 func fetchItemNames() (map[int]string, error) {
 	var items map[string]itemConstantsEntry
 	if err := getOpendotaJSON(baseURL+itemsURL, &items); err != nil {
@@ -213,6 +414,39 @@ func fetchItemNames() (map[int]string, error) {
 	return result, nil
 }
 
+// requestMatchParse ставит матч в очередь разбора OpenDota: линии и роли
+// (lane_role, is_roaming) появляются в деталях матча только после него.
+func requestMatchParse(matchID int64) error {
+	if opendotaLimiter != nil {
+		opendotaLimiter.Wait()
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Post(fmt.Sprintf(baseURL+parseRequestURL, matchID), "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("opendota parse %d: %w", matchID, withoutURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("opendota parse %d failed: %s: %s", matchID, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func fetchCachedMatchDetails(matchID int64) (matchDetails, error) {
+	return matchCache.GetOrLoad(matchID, func() (matchDetails, error) {
+		return fetchMatchDetails(matchID)
+	})
+}
+
+func fetchCachedItemNames() (map[int]string, error) {
+	return itemsCache.GetOrLoad("items", fetchItemNames)
+}
+
+func fetchCachedHeroSlugs() (map[int]string, error) {
+	return itemsCache.GetOrLoad("hero_slugs", fetchHeroSlugs)
+}
+
 func sortItemNames(items []string) []string {
 	filtered := items[:0]
 	for _, item := range items {
@@ -226,7 +460,13 @@ func sortItemNames(items []string) []string {
 }
 
 func getOpendotaJSON(url string, out any) error {
-	return getJSON(url, out, opendotaLimiter)
+	start := time.Now()
+	err := getJSON(url, out, opendotaLimiter)
+	slog.Debug("opendota request", "url", url, "duration", time.Since(start), "error", err)
+	if err != nil {
+		return fmt.Errorf("opendota %s: %w", url, err)
+	}
+	return nil
 }
 
 func getJSON(url string, out any, limiter *rateLimiter) error {
@@ -236,16 +476,16 @@ func getJSON(url string, out any, limiter *rateLimiter) error {
 	client := &http.Client{Timeout: requestTimeout}
 	resp, err := client.Get(url)
 	if err != nil {
-		return fmt.Errorf("request %s: %w", url, err)
+		return fmt.Errorf("request: %w", withoutURL(err))
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return fmt.Errorf("request %s failed: %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+		return fmt.Errorf("request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
 	}
 	decoder := json.NewDecoder(resp.Body)
 	if err := decoder.Decode(out); err != nil {
-		return fmt.Errorf("decode %s: %w", url, err)
+		return fmt.Errorf("decode: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// trackedPlayer: Name — имя для вывода (displayName), поиск идёт и по
+// alias, и по имени в Steam.
+type trackedPlayer struct {
+	AccountID int64
+	Name      string
+	Alias     string
+	Persona   string
+}
+
+type scoredPlayer struct {
+	Player trackedPlayer
+	Score  int
+}
+
+// loadTrackedPlayers подставляет имена из кэша профилей; при ошибке
+// используется "Account <id>".
+func loadTrackedPlayers(accountIDs []int64) []trackedPlayer {
+	players := make([]trackedPlayer, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		player := trackedPlayer{AccountID: accountID, Name: fmt.Sprintf("Account %d", accountID), Alias: playerAliases.Get(accountID)}
+		if profile, err := fetchCachedPlayerProfile(accountID); err == nil {
+			player.Persona = profile.PersonaName
+			player.Name = displayName(accountID, profile.PersonaName)
+		} else if player.Alias != "" {
+			player.Name = player.Alias
+		}
+		players = append(players, player)
+	}
+	return players
+}
+
+// resolveTrackedPlayer ищет игрока по account_id или по имени.
+func resolveTrackedPlayer(players []trackedPlayer, query string) (trackedPlayer, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return trackedPlayer{}, newLocalizedError("err.player_required")
+	}
+	if id, err := parseSteamID(query); err == nil {
+		for _, player := range players {
+			if player.AccountID == id {
+				return player, nil
+			}
+		}
+	}
+	found := matchTrackedPlayers(players, query)
+	if len(found) == 0 {
+		return trackedPlayer{}, newLocalizedError("err.player_not_found", query)
+	}
+	return found[0], nil
+}
+
+// matchTrackedPlayers сортирует игроков по близости имени к запросу.
+// Пустой запрос возвращает всех игроков в исходном порядке.
+func matchTrackedPlayers(players []trackedPlayer, query string) []trackedPlayer {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return append([]trackedPlayer(nil), players...)
+	}
+	var matched []scoredPlayer
+	for _, player := range players {
+		best, found := 0, false
+		for _, name := range player.searchNames() {
+			if score, ok := fuzzyScore(strings.ToLower(name), query); ok && (!found || score < best) {
+				best, found = score, true
+			}
+		}
+		if !found {
+			continue
+		}
+		matched = append(matched, scoredPlayer{Player: player, Score: best})
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Score < matched[j].Score
+	})
+	result := make([]trackedPlayer, 0, len(matched))
+	for _, m := range matched {
+		result = append(result, m.Player)
+	}
+	return result
+}
+
+func (p trackedPlayer) searchNames() []string {
+	var names []string
+	for _, name := range []string{p.Alias, p.Persona} {
+		if strings.TrimSpace(name) != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// fuzzyScore возвращает оценку совпадения (меньше — лучше): точное совпадение,
+// префикс, подстрока, затем расстояние Левенштейна не больше трети длины запроса.
+func fuzzyScore(name string, query string) (int, bool) {
+	switch {
+	case name == query:
+		return 0, true
+	case strings.HasPrefix(name, query):
+		return 1, true
+	case strings.Contains(name, query):
+		return 2, true
+	}
+	maxDistance := runeLen(query) / 3
+	if maxDistance == 0 {
+		return 0, false
+	}
+	distance := levenshtein(name, query)
+	if prefix := []rune(name); len(prefix) > runeLen(query) {
+		if d := levenshtein(string(prefix[:runeLen(query)]), query); d < distance {
+			distance = d
+		}
+	}
+	if distance > maxDistance {
+		return 0, false
+	}
+	return 3 + distance, true
+}
+
+func levenshtein(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,60 @@
+package app
+
+import "testing"
+
+func TestMatchTrackedPlayers_Order(t *testing.T) {
+	players := []trackedPlayer{
+		{AccountID: 1, Name: "SuperNick"},
+		{AccountID: 2, Name: "Nick"},
+		{AccountID: 3, Name: "Other"},
+	}
+	// Точное совпадение должно быть выше подстроки, лишние игроки отфильтрованы.
+	got := matchTrackedPlayers(players, "nick")
+	if len(got) != 2 || got[0].AccountID != 2 || got[1].AccountID != 1 {
+		t.Fatalf("unexpected result: %#v", got)
+	}
+}
+
+func TestMatchTrackedPlayers_Typo(t *testing.T) {
+	players := []trackedPlayer{{AccountID: 1, Name: "Invoker"}, {AccountID: 2, Name: "Pudge"}}
+	// Опечатка в запросе должна находить ближайшее имя.
+	got := matchTrackedPlayers(players, "invokre")
+	if len(got) != 1 || got[0].AccountID != 1 {
+		t.Fatalf("unexpected result: %#v", got)
+	}
+}
+
+func TestMatchTrackedPlayers_EmptyQuery(t *testing.T) {
+	players := []trackedPlayer{{AccountID: 1, Name: "A"}, {AccountID: 2, Name: "B"}}
+	if got := matchTrackedPlayers(players, " "); len(got) != 2 {
+		t.Fatalf("len=%d, want 2", len(got))
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := map[[2]string]int{
+		{"", ""}:              0,
+		{"abc", "abc"}:        0,
+		{"kitten", "sitting"}: 3,
+		{"дота", "дотa"}:      1,
+	}
+	for in, want := range cases {
+		if got := levenshtein(in[0], in[1]); got != want {
+			t.Fatalf("levenshtein(%q, %q)=%d, want %d", in[0], in[1], got, want)
+		}
+	}
+}
+
+func TestResolveTrackedPlayer(t *testing.T) {
+	players := []trackedPlayer{{AccountID: 86745912, Name: "Alpha"}, {AccountID: 2, Name: "Beta"}}
+	// Поиск по account_id, SteamID64 и имени.
+	for _, query := range []string{"86745912", "76561198047011640", "alp"} {
+		got, err := resolveTrackedPlayer(players, query)
+		if err != nil || got.AccountID != 86745912 {
+			t.Fatalf("resolveTrackedPlayer(%q)=%+v, %v", query, got, err)
+		}
+	}
+	if _, err := resolveTrackedPlayer(players, "zzz"); err == nil {
+		t.Fatal("expected error for unknown player")
+	}
+}
@@ -0,0 +1,406 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	ratingWinrate = "winrate"
+	ratingKDA     = "kda"
+	ratingRank    = "rank"
+	ratingGames   = "games"
+	ratingGPM     = "gpm"
+	ratingStreak  = "streak"
+)
+
+type ratingEntry struct {
+	AccountID int64
+	Name      string
+	Games     int
+	Wins      int
+	KDA       float64
+	GPM       float64
+	RankTier  int
+	Streak    int
+}
+
+type ratingMetric struct {
+	Key       string
+	Precision int
+	Suffix    string
+	Value     func(e ratingEntry) float64
+	Display   func(e ratingEntry) string
+}
+
+// ratingMetrics перечислены в порядке кнопок под рейтингом.
+var ratingMetrics = []ratingMetric{
+	{
+		Key: ratingWinrate, Precision: 1, Suffix: "%",
+		Value: func(e ratingEntry) float64 {
+			if e.Games == 0 {
+				return 0
+			}
+			return float64(e.Wins) * 100 / float64(e.Games)
+		},
+	},
+	{Key: ratingKDA, Precision: 2, Value: func(e ratingEntry) float64 { return e.KDA }},
+	{
+		Key:     ratingRank,
+		Value:   func(e ratingEntry) float64 { return float64(e.RankTier) },
+		Display: func(e ratingEntry) string { return rankTierName(e.RankTier) },
+	},
+	{Key: ratingGames, Value: func(e ratingEntry) float64 { return float64(e.Games) }},
+	{Key: ratingGPM, Value: func(e ratingEntry) float64 { return e.GPM }},
+	{
+		Key:     ratingStreak,
+		Value:   func(e ratingEntry) float64 { return float64(e.Streak) },
+		Display: func(e ratingEntry) string { return formatStreak(e.Streak) },
+	},
+}
+
+var ratingMetricAliases = map[string]string{
+	"wr":      ratingWinrate,
+	"winrate": ratingWinrate,
+	"kda":     ratingKDA,
+	"rank":    ratingRank,
+	"ранг":    ratingRank,
+	"games":   ratingGames,
+	"игры":    ratingGames,
+	"gpm":     ratingGPM,
+	"streak":  ratingStreak,
+	"серия":   ratingStreak,
+}
+
+// ratingWindows — окна выборки на кнопках рейтинга; подпись — Count в
+// единицах Unit ("games" или "days" из каталога сообщений).
+var ratingWindows = []struct {
+	Token string
+	Count int
+	Unit  string
+}{
+	{"20", 20, "games"},
+	{"50", 50, "games"},
+	{"7d", 7, "days"},
+	{"30d", 30, "days"},
+}
+
+func lookupRatingMetric(key string) ratingMetric {
+	for _, metric := range ratingMetrics {
+		if metric.Key == key {
+			return metric
+		}
+	}
+	return ratingMetrics[0]
+}
+
+// Title — название метрики в заголовке и колонке таблицы.
+func (m ratingMetric) Title(loc locale) string {
+	return loc.T("metric." + m.Key)
+}
+
+// Button — подпись метрики на кнопке под рейтингом.
+func (m ratingMetric) Button(loc locale) string {
+	return loc.T("metric." + m.Key + ".button")
+}
+
+func (m ratingMetric) Format(e ratingEntry) string {
+	if m.Display != nil {
+		return m.Display(e)
+	}
+	return strconv.FormatFloat(m.Round(e), 'f', m.Precision, 64) + m.Suffix
+}
+
+// Round — значение метрики с точностью, с которой оно печатается.
+func (m ratingMetric) Round(e ratingEntry) float64 {
+	return roundTo(m.Value(e), m.Precision)
+}
+
+// parseRatingArgs разбирает "/rating [метрика] [фильтры]".
+func parseRatingArgs(raw []string) (commandArgs, error) {
+	metric := ratingWinrate
+	var filterTokens []string
+	for _, token := range raw {
+		if key, ok := ratingMetricAliases[strings.ToLower(token)]; ok {
+			metric = key
+			continue
+		}
+		filterTokens = append(filterTokens, token)
+	}
+	filter, err := parseMatchFilter(filterTokens, ratingDefaultGames, ratingMaxGames)
+	if err != nil {
+		return commandArgs{}, err
+	}
+	return commandArgs{Raw: raw, Limit: filter.Limit, Filter: filter, Sort: metric}, nil
+}
+
+func summarizeRating(accountID int64, name string, rankTier int, matches []recentMatch) ratingEntry {
+	entry := ratingEntry{AccountID: accountID, Name: name, RankTier: rankTier, Games: len(matches), Streak: currentStreak(matches)}
+	var kills, deaths, assists, gpm int
+	for _, m := range matches {
+		if matchWin(m) {
+			entry.Wins++
+		}
+		kills += m.Kills
+		deaths += m.Deaths
+		assists += m.Assists
+		gpm += m.GPM
+	}
+	if len(matches) > 0 {
+		entry.KDA = float64(kills+assists) / float64(max(deaths, 1))
+		entry.GPM = float64(gpm) / float64(len(matches))
+	}
+	return entry
+}
+
+// currentStreak считает серию с последнего матча: +N побед или -N поражений.
+func currentStreak(matches []recentMatch) int {
+	streak := 0
+	for i, m := range matches {
+		win := matchWin(m)
+		if i > 0 && win != (streak > 0) {
+			break
+		}
+		if win {
+			streak++
+		} else {
+			streak--
+		}
+	}
+	return streak
+}
+
+func formatStreak(streak int) string {
+	switch {
+	case streak > 0:
+		return fmt.Sprintf("%dW", streak)
+	case streak < 0:
+		return fmt.Sprintf("%dL", -streak)
+	}
+	return "-"
+}
+
+var rankMedals = [...]string{"", "Herald", "Guardian", "Crusader", "Archon", "Legend", "Ancient", "Divine", "Immortal"}
+
+// rankTierName переводит rank_tier OpenDota (десятки — медаль, единицы —
+// звёзды) в название.
+func rankTierName(tier int) string {
+	medal, stars := tier/10, tier%10
+	if medal <= 0 || medal >= len(rankMedals) {
+		return "-"
+	}
+	if medal == len(rankMedals)-1 || stars == 0 {
+		return rankMedals[medal]
+	}
+	return fmt.Sprintf("%s %d", rankMedals[medal], stars)
+}
+
+func sortRatingEntries(entries []ratingEntry, metric ratingMetric) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		vi, vj := metric.Value(entries[i]), metric.Value(entries[j])
+		if vi == vj {
+			return entries[i].Games > entries[j].Games
+		}
+		return vi > vj
+	})
+}
+
+type ratingPosition struct {
+	Place int
+	Value float64
+}
+
+type ratingSnapshot map[int64]ratingPosition
+
+func snapshotRating(entries []ratingEntry, metric ratingMetric) ratingSnapshot {
+	snapshot := make(ratingSnapshot, len(entries))
+	for i, e := range entries {
+		snapshot[e.AccountID] = ratingPosition{Place: i + 1, Value: metric.Value(e)}
+	}
+	return snapshot
+}
+
+// ratingHistory хранит в памяти последний показанный рейтинг для каждого
+// чата, метрики и окна, чтобы показывать изменения с прошлого запроса.
+type ratingHistory struct {
+	mu   sync.Mutex
+	last map[string]ratingSnapshot
+}
+
+func newRatingHistory() *ratingHistory {
+	return &ratingHistory{last: make(map[string]ratingSnapshot)}
+}
+
+// Swap сохраняет новый снимок и возвращает предыдущий.
+func (h *ratingHistory) Swap(key string, snapshot ratingSnapshot) ratingSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	previous := h.last[key]
+	h.last[key] = snapshot
+	return previous
+}
+
+func ratingHistoryKey(chatID int64, metric string, filter matchFilter) string {
+	return fmt.Sprintf("%d|%s|%s", chatID, metric, strings.Join(filter.Tokens(), " "))
+}
+
+// ratingRow — строка рейтинга с местом; её печатают и таблица, и выгрузка.
+type ratingRow struct {
+	Place int
+	ratingEntry
+}
+
+func buildRatingRows(entries []ratingEntry) []ratingRow {
+	rows := make([]ratingRow, len(entries))
+	for i, e := range entries {
+		rows[i] = ratingRow{Place: i + 1, ratingEntry: e}
+	}
+	return rows
+}
+
+func formatRatingTable(loc locale, entries []ratingEntry, metric ratingMetric, previous ratingSnapshot) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%-3s  %-16s  %9s  %-5s  %s\n", "№", loc.T("rating.player"), metric.Title(loc), loc.T("rating.games"), "Δ"))
+	for _, row := range buildRatingRows(entries) {
+		rank := strconv.Itoa(row.Place)
+		switch row.Place {
+		case 1:
+			rank = "🥇"
+		case 2:
+			rank = "🥈"
+		case 3:
+			rank = "🥉"
+		}
+		delta := ""
+		if previous != nil {
+			delta = formatRatingDelta(row.Place, metric, metric.Value(row.ratingEntry), previous, row.AccountID)
+		}
+		builder.WriteString(fmt.Sprintf("%-3s  %-16s  %9s  %-5d  %s\n", rank, trimTo(row.Name, 16), metric.Format(row.ratingEntry), row.Games, delta))
+	}
+	return builder.String()
+}
+
+func formatRatingDelta(place int, metric ratingMetric, value float64, previous ratingSnapshot, accountID int64) string {
+	before, ok := previous[accountID]
+	if !ok {
+		return "new"
+	}
+	move := "="
+	switch {
+	case place < before.Place:
+		move = fmt.Sprintf("↑%d", before.Place-place)
+	case place > before.Place:
+		move = fmt.Sprintf("↓%d", place-before.Place)
+	}
+	diff := value - before.Value
+	if metric.Display != nil || diff == 0 {
+		return move
+	}
+	return fmt.Sprintf("%s %+.*f", move, metric.Precision, diff)
+}
+
+func loadRatingEntries(accountIDs []int64, filter matchFilter) ([]ratingEntry, error) {
+	entries := make([]ratingEntry, 0, len(accountIDs))
+	for _, accountID := range accountIDs {
+		player, err := fetchCachedPlayerProfile(accountID)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := fetchFilteredMatchStats(accountID, filter)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, summarizeRating(accountID, displayName(accountID, player.PersonaName), player.RankTier, matches))
+	}
+	return entries, nil
+}
+
+// buildRatingMessage строит рейтинг и запоминает его для сравнения при
+// следующем запросе в этом чате.
+func (b *telegramBot) buildRatingMessage(loc locale, chatID int64, metricKey string, filter matchFilter) (string, error) {
+	entries, err := loadRatingEntries(b.accountStore.Get(), filter)
+	if err != nil {
+		return "", err
+	}
+	metric := lookupRatingMetric(metricKey)
+	sortRatingEntries(entries, metric)
+	previous := b.ratings.Swap(ratingHistoryKey(chatID, metric.Key, filter), snapshotRating(entries, metric))
+	header := "<b>" + escapeHTML(loc.T("rating.title", metric.Title(loc), filter.DescribeIn(loc))) + "</b>\n"
+	return header + "<pre>" + escapeHTML(formatRatingTable(loc, entries, metric, previous)) + "</pre>", nil
+}
+
+// buildRatingMarkup рисует переключатели метрики и окна. Текущие значения
+// отмечены точкой и ничего не делают при нажатии.
+func buildRatingMarkup(loc locale, metricKey string, filter matchFilter) any {
+	queue := []string{}
+	if filter.Queue != "" {
+		queue = append(queue, filter.Queue)
+	}
+	current := strings.Join(filter.Tokens(), ",")
+	button := func(label string, selected bool, metric string, tokens []string) map[string]string {
+		if selected {
+			return map[string]string{"text": "• " + label, "callback_data": callbackRating + ":" + callbackRatingNoop}
+		}
+		return map[string]string{
+			"text":          label,
+			"callback_data": fmt.Sprintf("%s:%s:%s", callbackRating, metric, strings.Join(tokens, ",")),
+		}
+	}
+	var metricRow []map[string]string
+	var rows [][]map[string]string
+	for _, metric := range ratingMetrics {
+		metricRow = append(metricRow, button(metric.Button(loc), metric.Key == metricKey, metric.Key, filter.Tokens()))
+		if len(metricRow) == 3 {
+			rows = append(rows, metricRow)
+			metricRow = nil
+		}
+	}
+	if len(metricRow) > 0 {
+		rows = append(rows, metricRow)
+	}
+	var windowRow []map[string]string
+	for _, window := range ratingWindows {
+		tokens := append([]string{window.Token}, queue...)
+		windowRow = append(windowRow, button(loc.N(window.Count, window.Unit), strings.Join(tokens, ",") == current, metricKey, tokens))
+	}
+	rows = append(rows, windowRow)
+	return map[string]any{"inline_keyboard": rows}
+}
+
+// parseRatingCallbackData разбирает "rating:<метрика>:<токены фильтра через запятую>".
+func parseRatingCallbackData(data string) (commandArgs, bool) {
+	parts := strings.SplitN(data, ":", 3)
+	if len(parts) != 3 || parts[0] != callbackRating {
+		return commandArgs{}, false
+	}
+	raw := []string{parts[1]}
+	if parts[2] != "" {
+		raw = append(raw, strings.Split(parts[2], ",")...)
+	}
+	args, err := parseRatingArgs(raw)
+	if err != nil {
+		return commandArgs{}, false
+	}
+	return args, true
+}
+
+func (b *telegramBot) handleRatingCallback(query *telegramCallbackQuery) error {
+	args, ok := parseRatingCallbackData(query.Data)
+	if !ok || query.Message == nil {
+		return answerTelegramCallback(b.apiBase, query.ID, "")
+	}
+	chatID := query.Message.Chat.ID
+	loc := localeFor(chatID)
+	if err := answerTelegramCallback(b.apiBase, query.ID, loc.T("notice.rating")); err != nil {
+		return err
+	}
+	text, err := b.buildRatingMessage(loc, chatID, args.Sort, args.Filter)
+	if err != nil {
+		b.sendError(chatID, err)
+		return nil
+	}
+	return editTelegramMessage(b.apiBase, chatID, query.Message.MessageID, text, "HTML", buildRatingMarkup(loc, args.Sort, args.Filter))
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRatingArgs(t *testing.T) {
+	args, err := parseRatingArgs([]string{"kda", "7d", "ranked"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Sort != ratingKDA || args.Filter.Days != 7 || args.Filter.Queue != "ranked" {
+		t.Fatalf("unexpected args: %+v", args)
+	}
+	args, err = parseRatingArgs(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Sort != ratingWinrate || args.Filter.Limit != ratingDefaultGames {
+		t.Fatalf("unexpected defaults: %+v", args)
+	}
+}
+
+func TestCurrentStreak(t *testing.T) {
+	win := recentMatch{PlayerSlot: 0, RadiantWin: true}
+	loss := recentMatch{PlayerSlot: 0, RadiantWin: false}
+	cases := []struct {
+		matches []recentMatch
+		want    int
+	}{
+		{nil, 0},
+		{[]recentMatch{win, win, loss, win}, 2},
+		{[]recentMatch{loss, loss, loss, win}, -3},
+	}
+	for _, c := range cases {
+		if got := currentStreak(c.matches); got != c.want {
+			t.Fatalf("currentStreak=%d, want %d", got, c.want)
+		}
+	}
+}
+
+func TestRankTierName(t *testing.T) {
+	cases := map[int]string{0: "-", 11: "Herald 1", 54: "Legend 4", 80: "Immortal", 95: "-"}
+	for tier, want := range cases {
+		if got := rankTierName(tier); got != want {
+			t.Fatalf("rankTierName(%d)=%q, want %q", tier, got, want)
+		}
+	}
+}
+
+func TestSummarizeAndSortRating(t *testing.T) {
+	a := summarizeRating(1, "Alpha", 54, []recentMatch{
+		{Kills: 10, Deaths: 2, Assists: 10, GPM: 600, RadiantWin: true},
+		{Kills: 0, Deaths: 8, Assists: 0, GPM: 200, RadiantWin: false},
+	})
+	if a.Wins != 1 || a.KDA != 2 || a.GPM != 400 || a.Streak != 1 {
+		t.Fatalf("unexpected summary: %+v", a)
+	}
+	b := ratingEntry{AccountID: 2, Name: "Bravo", Games: 5, Wins: 1, KDA: 3, RankTier: 70}
+	entries := []ratingEntry{a, b}
+	sortRatingEntries(entries, lookupRatingMetric(ratingWinrate))
+	if entries[0].AccountID != 1 {
+		t.Fatalf("winrate order: %+v", entries)
+	}
+	sortRatingEntries(entries, lookupRatingMetric(ratingRank))
+	if entries[0].AccountID != 2 {
+		t.Fatalf("rank order: %+v", entries)
+	}
+}
+
+func TestFormatRatingTableDeltas(t *testing.T) {
+	metric := lookupRatingMetric(ratingWinrate)
+	entries := []ratingEntry{
+		{AccountID: 1, Name: "Alpha", Games: 10, Wins: 7},
+		{AccountID: 2, Name: "Bravo", Games: 10, Wins: 5},
+		{AccountID: 3, Name: "Charlie", Games: 10, Wins: 4},
+	}
+	previous := ratingSnapshot{
+		1: {Place: 2, Value: 60},
+		2: {Place: 1, Value: 50},
+	}
+	table := formatRatingTable(newLocale(langRU), entries, metric, previous)
+	lines := strings.Split(table, "\n")
+	if !strings.HasSuffix(lines[1], "↑1 +10.0") {
+		t.Fatalf("Alpha delta: %q", lines[1])
+	}
+	if !strings.HasSuffix(lines[2], "↓1") {
+		t.Fatalf("Bravo delta: %q", lines[2])
+	}
+	if !strings.HasSuffix(lines[3], "new") {
+		t.Fatalf("Charlie delta: %q", lines[3])
+	}
+	// Без прошлого снимка колонка изменений пустая.
+	if strings.Contains(formatRatingTable(newLocale(langRU), entries, metric, nil), "new") {
+		t.Fatal("unexpected deltas without history")
+	}
+}
+
+func TestRatingHistorySwap(t *testing.T) {
+	history := newRatingHistory()
+	key := ratingHistoryKey(1, ratingKDA, matchFilter{Days: 7})
+	if prev := history.Swap(key, ratingSnapshot{1: {Place: 1}}); prev != nil {
+		t.Fatalf("unexpected previous snapshot: %v", prev)
+	}
+	if prev := history.Swap(key, ratingSnapshot{}); prev[1].Place != 1 {
+		t.Fatalf("previous snapshot not returned: %v", prev)
+	}
+	if key == ratingHistoryKey(2, ratingKDA, matchFilter{Days: 7}) {
+		t.Fatal("history must be per chat")
+	}
+}
+
+func TestRatingCallbackRoundTrip(t *testing.T) {
+	filter := matchFilter{Days: 30, Queue: "turbo"}
+	markup := buildRatingMarkup(newLocale(langRU), ratingGPM, filter).(map[string]any)
+	rows := markup["inline_keyboard"].([][]map[string]string)
+	var kda, current string
+	for _, row := range rows {
+		for _, button := range row {
+			if button["text"] == "KDA" {
+				kda = button["callback_data"]
+			}
+			if button["text"] == "• GPM" {
+				current = button["callback_data"]
+			}
+		}
+	}
+	if _, ok := parseRatingCallbackData(current); ok {
+		t.Fatalf("selected button must be a no-op: %q", current)
+	}
+	args, ok := parseRatingCallbackData(kda)
+	if !ok {
+		t.Fatalf("failed to parse %q", kda)
+	}
+	if args.Sort != ratingKDA || args.Filter.Days != 30 || args.Filter.Queue != "turbo" {
+		t.Fatalf("unexpected args: %+v", args)
+	}
+	if len(kda) > 64 {
+		t.Fatalf("callback data too long: %q", kda)
+	}
+}
@@ -3,7 +3,6 @@ package app
 import (
 	"fmt"
 	"io"
-	"sort"
 	"strings"
 	"time"
 )
@@ -12,9 +11,10 @@ type matchNotification struct {
 	Text      string
 	MatchID   int64
 	AccountID int64
+	Match     recentMatch
 }
 
-func buildReport(accountIDs []int64, heroes map[int]string) (string, error) {
+func buildReport(loc locale, accountIDs []int64, heroes map[int]string) (string, error) {
 	var builder strings.Builder
 	for i, accountID := range accountIDs {
 		player, err := fetchPlayerProfile(accountID)
@@ -31,7 +31,7 @@ func buildReport(accountIDs []int64, heroes map[int]string) (string, error) {
 			matches = matches[:10]
 		}
 
-		writeMatches(&builder, matches, heroes, player.PersonaName, true)
+		writeMatches(&builder, loc, matches, heroes, displayName(accountID, player.PersonaName), true)
 		if i < len(accountIDs)-1 {
 			builder.WriteString("\n\n")
 		}
@@ -39,71 +39,50 @@ func buildReport(accountIDs []int64, heroes map[int]string) (string, error) {
 	return builder.String(), nil
 }
 
-func buildPlayerTable(matches []recentMatch, heroes map[int]string, playerName string) string {
+func buildPlayerTable(loc locale, matches []recentMatch, heroes map[int]string, playerName string) string {
 	var builder strings.Builder
-	writeMatches(&builder, matches, heroes, playerName, false)
+	writeMatches(&builder, loc, matches, heroes, playerName, false)
 	return builder.String()
 }
 
-func buildRatingTable(accountIDs []int64) (string, error) {
-	type ratingEntry struct {
-		Name    string
-		Winrate float64
-		Games   int
-	}
-	entries := make([]ratingEntry, 0, len(accountIDs))
-	for _, accountID := range accountIDs {
-		player, err := fetchPlayerProfile(accountID)
-		if err != nil {
-			return "", err
-		}
-		matches, err := fetchPlayerMatches(accountID, 50)
-		if err != nil {
-			return "", err
-		}
-		winrate, games := calcWinrateWithCount(matches, 50)
-		name := player.PersonaName
-		if name == "" {
-			name = "неизвестный"
-		}
-		entries = append(entries, ratingEntry{
-			Name:    name,
-			Winrate: winrate,
-			Games:   games,
-		})
-	}
+type bestFriendEntry struct {
+	AccountID int64
+	Player    string
+	FriendID  int64
+	Friend    string
+	Winrate   float64
+	Games     int
+}
 
-	sort.Slice(entries, func(i, j int) bool {
-		if entries[i].Winrate == entries[j].Winrate {
-			return entries[i].Games > entries[j].Games
-		}
-		return entries[i].Winrate > entries[j].Winrate
-	})
+// playerMatches — матчи одного игрока для отчёта /stat и экспорта.
+type playerMatches struct {
+	AccountID int64
+	Name      string
+	Avatar    string
+	Matches   []recentMatch
+}
 
-	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("%-3s  %-16s  %-9s  %-5s\n", "№", "Игрок", "Winrate", "Игр"))
-	for i, e := range entries {
-		rank := fmt.Sprintf("%d", i+1)
-		switch i {
-		case 0:
-			rank = "🥇"
-		case 1:
-			rank = "🥈"
-		case 2:
-			rank = "🥉"
-		}
-		builder.WriteString(fmt.Sprintf("%-3s  %-16s  %7.1f%%  %-5d\n", rank, trimTo(e.Name, 16), e.Winrate, e.Games))
+func loadPlayerMatches(accountID int64, filter matchFilter) (playerMatches, error) {
+	player, err := fetchPlayerProfile(accountID)
+	if err != nil {
+		return playerMatches{}, err
 	}
-	return builder.String(), nil
+	matches, err := fetchFilteredMatches(accountID, filter)
+	if err != nil {
+		return playerMatches{}, err
+	}
+	return playerMatches{AccountID: accountID, Name: displayName(accountID, player.PersonaName), Avatar: player.AvatarFull, Matches: matches}, nil
 }
 
-func buildBestFriendsTable(accountIDs []int64, limit int) (string, error) {
-	type bestFriendEntry struct {
-		Player  string
-		Friend  string
-		Winrate float64
-		Games   int
+func buildBestFriendsTable(loc locale, accountIDs []int64, filter matchFilter) (string, error) {
+	entries, err := loadBestFriends(accountIDs, filter)
+	if err != nil {
+		return "", err
 	}
+	return formatBestFriendsTable(loc, entries), nil
+}
+
+func loadBestFriends(accountIDs []int64, filter matchFilter) ([]bestFriendEntry, error) {
 	allowedFriends := make(map[int64]struct{}, len(accountIDs))
 	for _, id := range accountIDs {
 		allowedFriends[id] = struct{}{}
@@ -112,16 +91,15 @@ func buildBestFriendsTable(accountIDs []int64, limit int) (string, error) {
 	for _, id := range accountIDs {
 		player, err := fetchPlayerProfile(id)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		nameByID[id] = fallbackName(player.PersonaName)
+		nameByID[id] = displayName(id, player.PersonaName)
 	}
 	entries := make([]bestFriendEntry, 0, len(accountIDs))
 	for _, accountID := range accountIDs {
-		playerName := nameByID[accountID]
 		best := bestFriendEntry{
-			Player: playerName,
-			Friend: "нет данных",
+			AccountID: accountID,
+			Player:    nameByID[accountID],
 		}
 		for _, friendID := range accountIDs {
 			if friendID == accountID {
@@ -130,9 +108,9 @@ func buildBestFriendsTable(accountIDs []int64, limit int) (string, error) {
 			if _, ok := allowedFriends[friendID]; !ok {
 				continue
 			}
-			matches, err := fetchMatchesWith(accountID, friendID, limit)
+			matches, err := fetchMatchesWith(accountID, friendID, filter)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 			winrate, games := calcWinrateFromMatches(matches)
 			if games == 0 {
@@ -143,6 +121,7 @@ func buildBestFriendsTable(accountIDs []int64, limit int) (string, error) {
 				if name == "" {
 					name = fmt.Sprintf("Account %d", friendID)
 				}
+				best.FriendID = friendID
 				best.Friend = name
 				best.Winrate = winrate
 				best.Games = games
@@ -150,37 +129,74 @@ func buildBestFriendsTable(accountIDs []int64, limit int) (string, error) {
 		}
 		entries = append(entries, best)
 	}
+	return entries, nil
+}
 
+// formatBestFriendsTable печатает таблицу напарников; у игрока без общих
+// игр вместо друга пишется "нет данных".
+func formatBestFriendsTable(loc locale, entries []bestFriendEntry) string {
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("%-16s  %-16s  %-9s  %-5s\n", "Игрок", "Лучший друг", "Winrate", "Игр"))
+	builder.WriteString(fmt.Sprintf("%-16s  %-16s  %-9s  %-5s\n", loc.T("friends.player"), loc.T("friends.friend"), "Winrate", loc.T("friends.games")))
 	for _, e := range entries {
-		builder.WriteString(fmt.Sprintf("%-16s  %-16s  %7.1f%%  %-5d\n", trimTo(e.Player, 16), trimTo(e.Friend, 16), e.Winrate, e.Games))
+		friend := e.Friend
+		if friend == "" {
+			friend = loc.T("no_data")
+		}
+		builder.WriteString(fmt.Sprintf("%-16s  %-16s  %7.1f%%  %-5d\n", trimTo(e.Player, 16), trimTo(friend, 16), e.Winrate, e.Games))
 	}
-	return builder.String(), nil
+	return builder.String()
 }
 
-func writeMatches(writer io.Writer, matches []recentMatch, heroes map[int]string, playerName string, includeTitle bool) {
-	if playerName == "" {
-		playerName = "неизвестный"
-	}
-	if includeTitle {
-		fmt.Fprintf(writer, "Последние матчи (%s):\n", playerName)
-	}
-	fmt.Fprintf(writer, "%-16s  %-12s  %-4s  %-7s  %-6s\n", "Дата", "Герой", "Итог", "K/D/A", "Длит.")
+// matchRow — строка отчёта о матчах. Из одних и тех же строк печатается
+// текстовая таблица и строится выгрузка, чтобы они не расходились.
+type matchRow struct {
+	AccountID int64
+	Player    string
+	MatchID   int64
+	Start     time.Time
+	Hero      string
+	Win       bool
+	Kills     int
+	Deaths    int
+	Assists   int
+	Duration  int
+}
+
+func buildMatchRows(loc locale, accountID int64, playerName string, matches []recentMatch, heroes map[int]string) []matchRow {
+	rows := make([]matchRow, 0, len(matches))
 	for _, m := range matches {
 		heroName := heroes[m.HeroID]
 		if heroName == "" {
 			heroName = fmt.Sprintf("Hero #%d", m.HeroID)
 		}
-		win := matchWin(m)
+		rows = append(rows, matchRow{
+			AccountID: accountID, Player: playerName, MatchID: m.MatchID,
+			Start: loc.Time(m.StartTime), Hero: heroName, Win: matchWin(m),
+			Kills: m.Kills, Deaths: m.Deaths, Assists: m.Assists, Duration: m.Duration,
+		})
+	}
+	return rows
+}
+
+func writeMatches(writer io.Writer, loc locale, matches []recentMatch, heroes map[int]string, playerName string, includeTitle bool) {
+	if playerName == "" {
+		playerName = loc.T("unknown")
+	}
+	if includeTitle {
+		fmt.Fprintln(writer, loc.T("matches.title", playerName))
+	}
+	writeMatchRows(writer, loc, buildMatchRows(loc, 0, playerName, matches, heroes))
+}
+
+func writeMatchRows(writer io.Writer, loc locale, rows []matchRow) {
+	fmt.Fprintf(writer, "%-16s  %-12s  %-4s  %-7s  %-6s\n", loc.T("matches.date"), loc.T("matches.hero"), loc.T("matches.result"), "K/D/A", loc.T("matches.duration"))
+	for _, row := range rows {
 		result := "❌"
-		if win {
+		if row.Win {
 			result = "✅"
 		}
-		start := time.Unix(m.StartTime, 0).Local().Format("2006-01-02 15:04")
-		duration := formatDuration(m.Duration)
-		kda := fmt.Sprintf("%d/%d/%d", m.Kills, m.Deaths, m.Assists)
-		fmt.Fprintf(writer, "%-16s  %-12s  %-4s  %-7s  %-6s\n", start, trimTo(heroName, 12), result, kda, duration)
+		kda := fmt.Sprintf("%d/%d/%d", row.Kills, row.Deaths, row.Assists)
+		fmt.Fprintf(writer, "%-16s  %-12s  %-4s  %-7s  %-6s\n", row.Start.Format("2006-01-02 15:04"), trimTo(row.Hero, 12), result, kda, formatDuration(row.Duration))
 	}
 }
 
@@ -193,6 +209,9 @@ func formatMatchSummary(playerName string, match recentMatch, heroes map[int]str
 	if matchWin(match) {
 		result = "✅"
 	}
+	if role := matchRole(match); role != roleUnknown {
+		heroName += " (" + roleShortNames[role] + ")"
+	}
 	duration := formatDuration(match.Duration)
 	kda := fmt.Sprintf("%d/%d/%d", match.Kills, match.Deaths, match.Assists)
 	return fmt.Sprintf("%s | %s | %s | %s | %s", result, fallbackName(playerName), heroName, kda, duration)
@@ -200,7 +219,7 @@ func formatMatchSummary(playerName string, match recentMatch, heroes map[int]str
 
 func buildTestMatchSummary(accountIDs []int64, heroes map[int]string) (matchNotification, error) {
 	if len(accountIDs) == 0 {
-		return matchNotification{}, fmt.Errorf("нет аккаунтов для тестового сообщения")
+		return matchNotification{}, newLocalizedError("err.test_no_accounts")
 	}
 
 	for _, accountID := range accountIDs {
@@ -218,13 +237,40 @@ func buildTestMatchSummary(accountIDs []int64, heroes map[int]string) (matchNoti
 		}
 
 		return matchNotification{
-			Text:      formatMatchSummary(player.PersonaName, matches[0], heroes),
+			Text:      formatMatchSummary(displayName(accountID, player.PersonaName), matches[0], heroes),
 			MatchID:   matches[0].MatchID,
 			AccountID: accountID,
+			Match:     matches[0],
 		}, nil
 	}
 
-	return matchNotification{}, fmt.Errorf("не найдено ни одного матча для тестового сообщения")
+	return matchNotification{}, newLocalizedError("err.test_no_matches")
+}
+
+func buildMatchNotificationFromDetails(details matchDetails, accountID int64, heroes map[int]string) (matchNotification, error) {
+	player := findPlayerInMatch(details, accountID)
+	if player == nil {
+		return matchNotification{}, newLocalizedError("err.player_not_in_match")
+	}
+	match := recentMatch{
+		MatchID:    details.MatchID,
+		HeroID:     player.HeroID,
+		Kills:      player.Kills,
+		Deaths:     player.Deaths,
+		Assists:    player.Assists,
+		Duration:   details.Duration,
+		StartTime:  details.StartTime,
+		PlayerSlot: player.PlayerSlot,
+		RadiantWin: details.RadiantWin,
+		LaneRole:   player.LaneRole,
+		IsRoaming:  player.IsRoaming,
+	}
+	return matchNotification{
+		Text:      formatMatchSummary(displayName(accountID, player.PersonaName), match, heroes),
+		MatchID:   details.MatchID,
+		AccountID: accountID,
+		Match:     match,
+	}, nil
 }
 
 func findPlayerInMatch(details matchDetails, accountID int64) *matchDetailsPlayer {
@@ -236,10 +282,10 @@ func findPlayerInMatch(details matchDetails, accountID int64) *matchDetailsPlaye
 	return nil
 }
 
-func formatMatchDetailsMessage(details matchDetails, accountID int64, heroes map[int]string, itemNames map[int]string) (string, error) {
+func formatMatchDetailsMessage(loc locale, details matchDetails, accountID int64, heroes map[int]string, itemNames map[int]string) (string, error) {
 	player := findPlayerInMatch(details, accountID)
 	if player == nil {
-		return "", fmt.Errorf("игрок не найден в деталях матча")
+		return "", newLocalizedError("err.player_not_in_match")
 	}
 
 	heroName := heroes[player.HeroID]
@@ -247,31 +293,31 @@ func formatMatchDetailsMessage(details matchDetails, accountID int64, heroes map
 		heroName = fmt.Sprintf("Hero #%d", player.HeroID)
 	}
 
-	result := "❌ Поражение"
+	result := loc.T("details.loss")
 	if matchWin(recentMatch{PlayerSlot: player.PlayerSlot, RadiantWin: details.RadiantWin}) {
-		result = "✅ Победа"
+		result = loc.T("details.win")
 	}
 
 	itemList := collectPlayerItems(*player, itemNames)
-	itemsText := "нет данных"
+	itemsText := loc.T("no_data")
 	if len(itemList) > 0 {
 		itemsText = strings.Join(itemList, ", ")
 	}
 
 	lines := []string{
 		fmt.Sprintf("<b>%s</b>", escapeHTML(result)),
-		fmt.Sprintf("<b>Игрок:</b> %s", escapeHTML(fallbackName(player.PersonaName))),
-		fmt.Sprintf("<b>Герой:</b> %s", escapeHTML(heroName)),
+		fmt.Sprintf("<b>%s:</b> %s", loc.T("details.player"), escapeHTML(displayName(accountID, player.PersonaName))),
+		fmt.Sprintf("<b>%s:</b> %s", loc.T("details.hero"), escapeHTML(heroName)),
 		fmt.Sprintf("<b>K/D/A:</b> <code>%d/%d/%d</code>", player.Kills, player.Deaths, player.Assists),
-		fmt.Sprintf("<b>Длительность:</b> <code>%s</code>", formatDuration(details.Duration)),
-		fmt.Sprintf("<b>Счёт:</b> <code>%d:%d</code>", details.RadiantScore, details.DireScore),
+		fmt.Sprintf("<b>%s:</b> <code>%s</code>", loc.T("details.duration"), formatDuration(details.Duration)),
+		fmt.Sprintf("<b>%s:</b> <code>%d:%d</code>", loc.T("details.score"), details.RadiantScore, details.DireScore),
 		fmt.Sprintf("<b>GPM/XPM:</b> <code>%d/%d</code>", player.GPM, player.XPM),
 		fmt.Sprintf("<b>LH/DN:</b> <code>%d/%d</code>", player.LastHits, player.Denies),
 		fmt.Sprintf("<b>Hero Damage:</b> <code>%d</code>", player.HeroDamage),
 		fmt.Sprintf("<b>Tower Damage:</b> <code>%d</code>", player.TowerDamage),
 		fmt.Sprintf("<b>Hero Healing:</b> <code>%d</code>", player.HeroHealing),
 		fmt.Sprintf("<b>Net Worth:</b> <code>%d</code>", player.NetWorth),
-		fmt.Sprintf("<b>Предметы:</b> %s", escapeHTML(itemsText)),
+		fmt.Sprintf("<b>%s:</b> %s", loc.T("details.items"), escapeHTML(itemsText)),
 		fmt.Sprintf("<b>Match ID:</b> <code>%d</code>", details.MatchID),
 		fmt.Sprintf("<a href=\"https://www.opendota.com/matches/%d\">OpenDota</a>", details.MatchID),
 	}
@@ -23,7 +23,7 @@ func TestBuildPlayerTable_BasicOutput(t *testing.T) {
 		RadiantWin: true,
 	}}
 	heroes := map[int]string{1: "Axe"}
-	out := buildPlayerTable(matches, heroes, "Player")
+	out := buildPlayerTable(newLocale(langRU), matches, heroes, "Player")
 
 	// Проверяем, что в выводе есть базовые элементы строки матча.
 	if !strings.Contains(out, "1970-01-01 00:00") {
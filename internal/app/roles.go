@@ -0,0 +1,255 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Роли по lane_role OpenDota; роум — отдельная роль, какой бы ни была линия.
+const (
+	roleUnknown = iota
+	roleSafe
+	roleMid
+	roleOff
+	roleJungle
+	roleRoam
+	roleCount
+)
+
+// roleShortNames — подписи роли в уведомлениях; текст уведомления общий
+// для всех чатов, поэтому без перевода.
+var roleShortNames = [roleCount]string{"", "safe", "mid", "off", "jungle", "roam"}
+
+// rolesOrder — порядок строк /roles: неизвестная роль в конце.
+var rolesOrder = []int{roleSafe, roleMid, roleOff, roleJungle, roleRoam, roleUnknown}
+
+type roleStats struct {
+	Games int
+	Wins  int
+}
+
+func (s roleStats) winrate() string {
+	if s.Games == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(s.Wins)*100/float64(s.Games))
+}
+
+// matchRole определяет роль игрока в матче; для неразобранных матчей —
+// roleUnknown.
+func matchRole(m recentMatch) int {
+	if m.IsRoaming {
+		return roleRoam
+	}
+	if m.LaneRole >= roleSafe && m.LaneRole <= roleJungle {
+		return m.LaneRole
+	}
+	return roleUnknown
+}
+
+func calcRoleStats(matches []recentMatch) [roleCount]roleStats {
+	var stats [roleCount]roleStats
+	for _, m := range matches {
+		role := matchRole(m)
+		stats[role].Games++
+		if matchWin(m) {
+			stats[role].Wins++
+		}
+	}
+	return stats
+}
+
+// calcLaneCounts переводит lane_role из /players/{id}/counts в роли. Роума
+// там нет, он остаётся пустым.
+func calcLaneCounts(counts playerCounts) [roleCount]roleStats {
+	var stats [roleCount]roleStats
+	for key, entry := range counts.LaneRole {
+		role, err := strconv.Atoi(key)
+		if err != nil || role < roleSafe || role > roleJungle {
+			role = roleUnknown
+		}
+		stats[role].Games += entry.Games
+		stats[role].Wins += entry.Win
+	}
+	return stats
+}
+
+func buildRolesTable(loc locale, accountID int64, limit int) (string, int, error) {
+	counts, err := fetchPlayerCounts(accountID)
+	if err != nil {
+		return "", 0, err
+	}
+	matches, err := fetchPlayerMatchStats(accountID, limit)
+	if err != nil {
+		return "", 0, err
+	}
+	return formatRolesTable(loc, calcLaneCounts(counts), calcRoleStats(matches)), len(matches), nil
+}
+
+// formatRolesTable печатает роли за всё время (по counts) и за последние
+// игры (по матчам). Роли, которых нет ни там, ни там, пропускаются.
+func formatRolesTable(loc locale, total [roleCount]roleStats, recent [roleCount]roleStats) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%-12s  %-6s  %-7s  %-6s  %-7s\n",
+		loc.T("roles.role"), loc.T("roles.total"), "WR", loc.T("roles.recent"), "WR"))
+	games := func(s roleStats) string {
+		if s.Games == 0 {
+			return "-"
+		}
+		return strconv.Itoa(s.Games)
+	}
+	for _, role := range rolesOrder {
+		if total[role].Games == 0 && recent[role].Games == 0 {
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%-12s  %-6s  %7s  %-6s  %7s\n",
+			trimTo(loc.T(fmt.Sprintf("role.%d", role)), 12),
+			games(total[role]), total[role].winrate(), games(recent[role]), recent[role].winrate()))
+	}
+	return builder.String()
+}
+
+// roleTarget — уведомления об игроке, в которые ещё не дописана роль.
+type roleTarget struct {
+	AccountID int64
+	Messages  []telegramMessage
+}
+
+// roleUpdater дописывает роль игроков в отправленные уведомления. Линии
+// OpenDota знает только после разбора матча, а к моменту уведомления он
+// обычно ещё не разобран. Поэтому матч один раз ставится в очередь разбора
+// и раз в roleParseDelay перечитывается — одна проверка на матч, сколько бы
+// отслеживаемых игроков в нём ни было. Роль показывается по возможности:
+// если за roleParseRetries попыток разбора нет, уведомления остаются без неё.
+type roleUpdater struct {
+	heroes map[int]string
+	edit   func(message telegramMessage, text string, replyMarkup any) error
+
+	mu      sync.Mutex
+	targets map[int64][]roleTarget
+}
+
+func newRoleUpdater(apiBase string, heroes map[int]string) *roleUpdater {
+	return &roleUpdater{
+		heroes: heroes,
+		edit: func(message telegramMessage, text string, replyMarkup any) error {
+			if len(message.Photo) > 0 {
+				return editTelegramCaption(apiBase, message.Chat.ID, message.MessageID, text, "", replyMarkup)
+			}
+			return editTelegramMessage(apiBase, message.Chat.ID, message.MessageID, text, "", replyMarkup)
+		},
+		targets: make(map[int64][]roleTarget),
+	}
+}
+
+// Add запоминает отправленные уведомления об игроке и, если матч ещё
+// не проверяется, запускает его проверку.
+func (u *roleUpdater) Add(msg matchNotification, sent []telegramMessage) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	_, watching := u.targets[msg.MatchID]
+	u.targets[msg.MatchID] = append(u.targets[msg.MatchID], roleTarget{AccountID: msg.AccountID, Messages: sent})
+	if !watching {
+		go u.watch(msg.MatchID)
+	}
+}
+
+func (u *roleUpdater) watch(matchID int64) {
+	if err := requestMatchParse(matchID); err != nil {
+		slog.Warn("match parse request failed", "match_id", matchID, "error", err)
+	}
+	for attempt := 1; attempt <= roleParseRetries; attempt++ {
+		time.Sleep(roleParseDelay)
+		details, err := fetchMatchDetails(matchID)
+		if err != nil {
+			slog.Warn("match details fetch failed", "match_id", matchID, "attempt", attempt, "error", err)
+			continue
+		}
+		if u.apply(details) {
+			return
+		}
+	}
+	u.mu.Lock()
+	left := len(u.targets[matchID])
+	delete(u.targets, matchID)
+	u.mu.Unlock()
+	slog.Info("match not parsed, role unknown", "match_id", matchID, "players", left)
+}
+
+// apply дописывает роль в уведомления игроков, для которых она уже есть
+// в details, и сообщает, что ждать больше некого. Детали с ролями
+// попадают в кэш, поэтому и раскрытое уведомление, которое здесь
+// не правится, покажет роль после "Свернуть".
+func (u *roleUpdater) apply(details matchDetails) bool {
+	type update struct {
+		message telegramMessage
+		msg     matchNotification
+	}
+	var updates []update
+	u.mu.Lock()
+	var left []roleTarget
+	for _, target := range u.targets[details.MatchID] {
+		msg, ok := roleNotification(details, target.AccountID, u.heroes)
+		if !ok {
+			left = append(left, target)
+			continue
+		}
+		for _, message := range target.Messages {
+			updates = append(updates, update{message: message, msg: msg})
+		}
+	}
+	if len(left) == 0 {
+		delete(u.targets, details.MatchID)
+	} else {
+		u.targets[details.MatchID] = left
+	}
+	u.mu.Unlock()
+
+	if len(updates) > 0 {
+		matchCache.Set(details.MatchID, details)
+	}
+	for _, item := range updates {
+		chatID := item.message.Chat.ID
+		if matchExpanded(chatID, item.message.MessageID) {
+			continue
+		}
+		markup := buildMatchDetailsMarkup(localeFor(chatID), item.msg)
+		if err := u.edit(item.message, item.msg.Text, markup); err != nil {
+			slog.Warn("match role not added", "chat_id", chatID, "match_id", details.MatchID, "error", err)
+		}
+	}
+	return len(left) == 0
+}
+
+// roleNotification собирает уведомление из деталей матча, если в них уже
+// есть роль игрока.
+func roleNotification(details matchDetails, accountID int64, heroes map[int]string) (matchNotification, bool) {
+	msg, err := buildMatchNotificationFromDetails(details, accountID, heroes)
+	if err != nil || matchRole(msg.Match) == roleUnknown {
+		return matchNotification{}, false
+	}
+	return msg, true
+}
+
+// messageRef — сообщение в чате.
+type messageRef struct {
+	ChatID    int64
+	MessageID int
+}
+
+// expandedMessages — уведомления, раскрытые кнопкой "Подробнее"; их
+// roleUpdater не трогает, чтобы не свернуть подробности у читателя.
+var expandedMessages = newTTLCache[messageRef, bool](expandedCacheTTL, expandedCacheSize)
+
+func setMatchExpanded(chatID int64, messageID int, expanded bool) {
+	expandedMessages.Set(messageRef{ChatID: chatID, MessageID: messageID}, expanded)
+}
+
+func matchExpanded(chatID int64, messageID int) bool {
+	expanded, _ := expandedMessages.Get(messageRef{ChatID: chatID, MessageID: messageID})
+	return expanded
+}
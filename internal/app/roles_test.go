@@ -0,0 +1,123 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalcRoleStats(t *testing.T) {
+	matches := []recentMatch{
+		{LaneRole: 1, PlayerSlot: 0, RadiantWin: true},
+		{LaneRole: 1, PlayerSlot: 128, RadiantWin: true},
+		{LaneRole: 2, PlayerSlot: 0, RadiantWin: true},
+		// Роум важнее линии.
+		{LaneRole: 3, IsRoaming: true, PlayerSlot: 0, RadiantWin: true},
+		{PlayerSlot: 0, RadiantWin: false},
+	}
+	stats := calcRoleStats(matches)
+	if stats[roleSafe] != (roleStats{Games: 2, Wins: 1}) {
+		t.Fatalf("safe=%+v", stats[roleSafe])
+	}
+	if stats[roleMid].Games != 1 || stats[roleRoam].Games != 1 || stats[roleOff].Games != 0 || stats[roleUnknown].Games != 1 {
+		t.Fatalf("stats=%+v", stats)
+	}
+}
+
+func TestFormatRolesTable(t *testing.T) {
+	counts := playerCounts{LaneRole: map[string]playerCountEntry{
+		"0": {Games: 10, Win: 5},
+		"1": {Games: 40, Win: 22},
+		"2": {Games: 20, Win: 8},
+	}}
+	recent := calcRoleStats([]recentMatch{{LaneRole: 1, RadiantWin: true}, {IsRoaming: true}})
+	table := formatRolesTable(newLocale(langRU), calcLaneCounts(counts), recent)
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	// Заголовок, лёгкая, мид, роум, нет данных; сложной и леса нет.
+	if len(lines) != 5 {
+		t.Fatalf("table=%q", table)
+	}
+	if !strings.HasPrefix(lines[1], "Лёгкая") || !strings.Contains(lines[1], "55.0%") || !strings.Contains(lines[1], "100.0%") {
+		t.Fatalf("safe line=%q", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "Роум") || !strings.Contains(lines[3], "-") {
+		t.Fatalf("roam line=%q", lines[3])
+	}
+	if en := formatRolesTable(newLocale(langEN), calcLaneCounts(counts), recent); !strings.Contains(en, "Safe lane") {
+		t.Fatalf("en=%q", en)
+	}
+}
+
+func TestFormatMatchSummaryRole(t *testing.T) {
+	heroes := map[int]string{1: "Axe"}
+	match := recentMatch{HeroID: 1, LaneRole: 2, Duration: 1800}
+	if got := formatMatchSummary("Alpha", match, heroes); !strings.Contains(got, "Axe (mid)") {
+		t.Fatalf("summary=%q", got)
+	}
+	match.LaneRole = 0
+	if got := formatMatchSummary("Alpha", match, heroes); strings.Contains(got, "(") {
+		t.Fatalf("unknown role shown: %q", got)
+	}
+}
+
+func TestRoleNotification(t *testing.T) {
+	heroes := map[int]string{1: "Axe"}
+	details := matchDetails{MatchID: 10, Duration: 1800, Players: []matchDetailsPlayer{{AccountID: 7, HeroID: 1}}}
+	// До разбора OpenDota роли нет — уведомление не правится.
+	if _, ok := roleNotification(details, 7, heroes); ok {
+		t.Fatal("unparsed match gave a role")
+	}
+	details.Players[0].LaneRole = 2
+	msg, ok := roleNotification(details, 7, heroes)
+	if !ok || !strings.Contains(msg.Text, "Axe (mid)") || msg.MatchID != 10 || msg.AccountID != 7 {
+		t.Fatalf("msg=%+v ok=%v", msg, ok)
+	}
+	if _, ok := roleNotification(details, 8, heroes); ok {
+		t.Fatal("role for a player not in the match")
+	}
+}
+
+func TestRoleUpdaterApply(t *testing.T) {
+	type edit struct {
+		chatID int64
+		text   string
+	}
+	var edits []edit
+	u := &roleUpdater{
+		heroes: map[int]string{1: "Axe", 2: "Lina"},
+		edit: func(message telegramMessage, text string, replyMarkup any) error {
+			edits = append(edits, edit{chatID: message.Chat.ID, text: text})
+			return nil
+		},
+		targets: map[int64][]roleTarget{},
+	}
+	// Два игрока одного матча — одна проверка, правки расходятся по их уведомлениям.
+	u.targets[77] = []roleTarget{
+		{AccountID: 7, Messages: []telegramMessage{{MessageID: 1, Chat: telegramChat{ID: 100}}, {MessageID: 2, Chat: telegramChat{ID: 200}}}},
+		{AccountID: 8, Messages: []telegramMessage{{MessageID: 3, Chat: telegramChat{ID: 100}}}},
+	}
+	// Раскрытое уведомление не сворачивается.
+	setMatchExpanded(200, 2, true)
+	details := matchDetails{MatchID: 77, Duration: 1800, Players: []matchDetailsPlayer{
+		{AccountID: 7, HeroID: 1, LaneRole: 2},
+		{AccountID: 8, HeroID: 2},
+	}}
+	if u.apply(details) {
+		t.Fatal("player without role should still be awaited")
+	}
+	if len(edits) != 1 || edits[0].chatID != 100 || !strings.Contains(edits[0].text, "Axe (mid)") {
+		t.Fatalf("edits=%+v", edits)
+	}
+	if got := u.targets[77]; len(got) != 1 || got[0].AccountID != 8 {
+		t.Fatalf("targets=%+v", got)
+	}
+	details.Players[1].IsRoaming = true
+	if !u.apply(details) {
+		t.Fatal("all roles known, watch should stop")
+	}
+	if len(edits) != 2 || !strings.Contains(edits[1].text, "Lina (roam)") {
+		t.Fatalf("edits=%+v", edits)
+	}
+	if _, ok := u.targets[77]; ok {
+		t.Fatal("match still watched")
+	}
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+type accountChange struct {
+	Old accountConfig
+	New accountConfig
+}
+
+// rosterDiff — изменения состава отслеживаемых аккаунтов после перечитывания.
+type rosterDiff struct {
+	Added   []accountConfig
+	Removed []accountConfig
+	Changed []accountChange
+}
+
+func (d rosterDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func diffRoster(previous []accountConfig, current []accountConfig) rosterDiff {
+	var diff rosterDiff
+	old := make(map[int64]accountConfig, len(previous))
+	for _, account := range previous {
+		old[account.ID] = account
+	}
+	seen := make(map[int64]struct{}, len(current))
+	for _, account := range current {
+		seen[account.ID] = struct{}{}
+		before, ok := old[account.ID]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, account)
+		case before != account:
+			diff.Changed = append(diff.Changed, accountChange{Old: before, New: account})
+		}
+	}
+	for _, account := range previous {
+		if _, ok := seen[account.ID]; !ok {
+			diff.Removed = append(diff.Removed, account)
+		}
+	}
+	return diff
+}
+
+// formatRosterDiff описывает изменения для админов; name подставляет имя
+// игрока по account_id, если alias не задан.
+func formatRosterDiff(loc locale, source string, total int, diff rosterDiff, name func(int64) string) string {
+	label := func(account accountConfig) string {
+		display := account.Alias
+		if display == "" && name != nil {
+			display = name(account.ID)
+		}
+		if display == "" {
+			return fmt.Sprintf("%d", account.ID)
+		}
+		return fmt.Sprintf("%s (%d)", display, account.ID)
+	}
+	var b strings.Builder
+	b.WriteString(loc.T("roster.updated", source, loc.N(total, "accounts")) + "\n")
+	for _, account := range diff.Added {
+		fmt.Fprintf(&b, "+ %s\n", label(account))
+	}
+	for _, account := range diff.Removed {
+		fmt.Fprintf(&b, "− %s\n", label(account))
+	}
+	for _, change := range diff.Changed {
+		var parts []string
+		if change.Old.Alias != change.New.Alias {
+			parts = append(parts, loc.T("roster.alias", change.Old.Alias, change.New.Alias))
+		}
+		if change.Old.Notify != change.New.Notify {
+			state := loc.T("roster.notify_off")
+			if change.New.Notify {
+				state = loc.T("roster.notify_on")
+			}
+			parts = append(parts, state)
+		}
+		fmt.Fprintf(&b, "~ %s: %s\n", label(change.New), strings.Join(parts, ", "))
+	}
+	return b.String()
+}
+
+// fileFingerprint — хеш содержимого файлов; отсутствие файла тоже часть
+// отпечатка, так что его появление или удаление считается изменением.
+func fileFingerprint(paths []string) string {
+	hash := sha256.New()
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		raw, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			fmt.Fprintf(hash, "%s\x00missing\x00", path)
+		case err != nil:
+			fmt.Fprintf(hash, "%s\x00error %s\x00", path, err.Error())
+		default:
+			fmt.Fprintf(hash, "%s\x00%d\x00", path, len(raw))
+			hash.Write(raw)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// watchAccountStore опрашивает файлы аккаунтов и применяет правки без
+// /reload. Ошибки разбора только логируются: остаётся прежний список.
+func watchAccountStore(store *accountIDStore, interval time.Duration, onChange func(rosterDiff)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		diff, changed, err := store.ReloadIfChanged()
+		if err != nil {
+			slog.Error("accounts reload failed", "source", store.Source(), "error", err)
+			continue
+		}
+		if changed {
+			slog.Info("accounts reloaded", "source", store.Source(), "accounts", len(store.Get()),
+				"added", len(diff.Added), "removed", len(diff.Removed), "changed", len(diff.Changed))
+		}
+		if changed && !diff.Empty() && onChange != nil {
+			onChange(diff)
+		}
+	}
+}
+
+// rosterPlayerName берёт имя из кэша профилей, не дёргая API без нужды.
+func rosterPlayerName(accountID int64) string {
+	profile, err := fetchCachedPlayerProfile(accountID)
+	if err != nil {
+		return ""
+	}
+	return fallbackName(profile.PersonaName)
+}
+
+// printRosterDiff — обработчик изменений для режимов без бота.
+func printRosterDiff(store *accountIDStore) func(rosterDiff) {
+	return func(diff rosterDiff) {
+		fmt.Print(formatRosterDiff(defaultLocale(), store.Source(), len(store.Get()), diff, rosterPlayerName))
+	}
+}
+
+// announceRoster сообщает администраторам об изменениях состава.
+func (b *telegramBot) announceRoster(diff rosterDiff) {
+	for adminID := range b.admins {
+		text := formatRosterDiff(localeFor(adminID), b.accountStore.Source(), len(b.accountStore.Get()), diff, rosterPlayerName)
+		if err := sendTelegramMessage(b.apiBase, adminID, text, "", nil); err != nil {
+			slog.Error("roster announce failed", "chat_id", adminID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffRoster(t *testing.T) {
+	previous := []accountConfig{{ID: 1, Notify: true}, {ID: 2, Alias: "Bob", Notify: true}, {ID: 3, Notify: true}}
+	current := []accountConfig{{ID: 2, Alias: "Боб", Notify: false}, {ID: 3, Notify: true}, {ID: 4, Notify: true}}
+	diff := diffRoster(previous, current)
+	if len(diff.Added) != 1 || diff.Added[0].ID != 4 {
+		t.Fatalf("added=%+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != 1 {
+		t.Fatalf("removed=%+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].New.Alias != "Боб" {
+		t.Fatalf("changed=%+v", diff.Changed)
+	}
+	if diffRoster(current, current).Empty() != true {
+		t.Fatal("same roster should give empty diff")
+	}
+
+	text := formatRosterDiff(newLocale(langRU), "account_id", 3, diff, func(id int64) string {
+		if id == 1 {
+			return "Alice"
+		}
+		return ""
+	})
+	for _, want := range []string{"3 аккаунта\n", "+ 4\n", "− Alice (1)\n", `~ Боб (2): alias "Bob" → "Боб", уведомления выключены`} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("text %q should contain %q", text, want)
+		}
+	}
+	en := formatRosterDiff(newLocale(langEN), "account_id", 1, diff, nil)
+	for _, want := range []string{"1 account\n", "notifications off"} {
+		if !strings.Contains(en, want) {
+			t.Fatalf("en %q should contain %q", en, want)
+		}
+	}
+	if ru := formatRosterDiff(newLocale(langRU), "account_id", 1, rosterDiff{}, nil); !strings.Contains(ru, "1 аккаунт\n") {
+		t.Fatalf("ru=%q", ru)
+	}
+}
+
+func TestAccountStoreReloadIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "account_id")
+	if err := os.WriteFile(path, []byte("1\n2\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	store, err := loadAccountIDStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, changed, err := store.ReloadIfChanged(); changed || err != nil {
+		t.Fatalf("untouched file: changed=%v err=%v", changed, err)
+	}
+
+	if err := os.WriteFile(path, []byte("2\n3\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	diff, changed, err := store.ReloadIfChanged()
+	if !changed || err != nil || len(diff.Added) != 1 || len(diff.Removed) != 1 {
+		t.Fatalf("changed=%v err=%v diff=%+v", changed, err, diff)
+	}
+	if ids := store.Get(); len(ids) != 2 || ids[0] != 2 || ids[1] != 3 {
+		t.Fatalf("ids=%v", ids)
+	}
+
+	// Битый файл: ошибка один раз, прежний список остаётся.
+	if err := os.WriteFile(path, []byte("oops\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, _, err := store.ReloadIfChanged(); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if _, changed, err := store.ReloadIfChanged(); changed || err != nil {
+		t.Fatalf("error should not repeat: changed=%v err=%v", changed, err)
+	}
+	if len(store.Get()) != 2 {
+		t.Fatalf("ids=%v", store.Get())
+	}
+}
+
+func TestPruneMonitorState(t *testing.T) {
+	lastMatch := map[int64]int64{1: 10, 2: 20}
+	names := map[int64]string{1: "A", 2: "B"}
+	pruneMonitorState([]int64{2, 3}, lastMatch, names)
+	if _, ok := lastMatch[1]; ok || len(lastMatch) != 1 {
+		t.Fatalf("lastMatch=%v", lastMatch)
+	}
+	if _, ok := names[1]; ok || len(names) != 1 {
+		t.Fatalf("names=%v", names)
+	}
+}
@@ -0,0 +1,306 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	scheduleDaily  = "daily"
+	scheduleWeekly = "weekly"
+)
+
+type schedule struct {
+	ID        int      `json:"id"`
+	ChatID    int64    `json:"chat_id"`
+	CreatedBy int64    `json:"created_by"`
+	Kind      string   `json:"kind"`
+	Weekday   int      `json:"weekday"`
+	Hour      int      `json:"hour"`
+	Minute    int      `json:"minute"`
+	Timezone  string   `json:"timezone"`
+	Command   string   `json:"command"`
+	Args      []string `json:"args"`
+}
+
+var scheduleWeekdays = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+	"пн": time.Monday, "вт": time.Tuesday, "ср": time.Wednesday, "чт": time.Thursday,
+	"пт": time.Friday, "сб": time.Saturday, "вс": time.Sunday,
+}
+
+// parseScheduleArgs разбирает
+// "/schedule daily|weekly <день> HH:MM [Area/City] <команда> [аргументы]".
+func parseScheduleArgs(raw []string) (commandArgs, error) {
+	usage := newLocalizedError("usage.schedule")
+	if len(raw) < 3 {
+		return commandArgs{}, usage
+	}
+	s := schedule{Kind: strings.ToLower(raw[0])}
+	rest := raw[1:]
+	switch s.Kind {
+	case scheduleDaily:
+	case scheduleWeekly:
+		day, ok := scheduleWeekdays[strings.ToLower(rest[0])]
+		if !ok {
+			return commandArgs{}, newLocalizedError("err.weekday", rest[0])
+		}
+		s.Weekday = int(day)
+		rest = rest[1:]
+	default:
+		return commandArgs{}, usage
+	}
+	if len(rest) < 2 {
+		return commandArgs{}, usage
+	}
+	hour, minute, err := parseClock(rest[0])
+	if err != nil {
+		return commandArgs{}, err
+	}
+	s.Hour, s.Minute = hour, minute
+	rest = rest[1:]
+	if isTimezoneToken(rest[0]) {
+		if _, err := time.LoadLocation(rest[0]); err != nil {
+			return commandArgs{}, newLocalizedError("err.timezone", rest[0])
+		}
+		s.Timezone = rest[0]
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return commandArgs{}, usage
+	}
+	s.Command = strings.ToLower(strings.TrimPrefix(rest[0], "/"))
+	s.Args = append([]string(nil), rest[1:]...)
+	return commandArgs{Raw: raw, Schedule: s}, nil
+}
+
+func isTimezoneToken(value string) bool {
+	if value == "UTC" {
+		return true
+	}
+	return strings.Contains(value, "/") && !strings.HasPrefix(value, "/")
+}
+
+func parseClock(value string) (int, int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return 0, 0, newLocalizedError("err.clock", value)
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, newLocalizedError("err.hour", value)
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, newLocalizedError("err.minute", value)
+	}
+	return hour, minute, nil
+}
+
+// Location — пояс, указанный в расписании, а без него — текущий пояс чата
+// из /tz или конфига.
+func (s schedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return localeFor(s.ChatID).Location(), nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// Next возвращает ближайший момент запуска строго после after.
+func (s schedule) Next(after time.Time) (time.Time, error) {
+	loc, err := s.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := after.In(loc)
+	candidate := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, s.Minute, 0, 0, loc)
+	if s.Kind == scheduleWeekly {
+		shift := (s.Weekday - int(candidate.Weekday()) + 7) % 7
+		candidate = candidate.AddDate(0, 0, shift)
+	}
+	for !candidate.After(after) {
+		if s.Kind == scheduleWeekly {
+			candidate = candidate.AddDate(0, 0, 7)
+		} else {
+			candidate = candidate.AddDate(0, 0, 1)
+		}
+	}
+	return candidate, nil
+}
+
+func (s schedule) Describe() string {
+	loc := localeFor(s.ChatID)
+	when := loc.T("schedule.daily")
+	if s.Kind == scheduleWeekly {
+		when = loc.T("schedule.weekly", loc.T(fmt.Sprintf("weekday.%d", s.Weekday)))
+	}
+	tz := s.Timezone
+	if tz == "" {
+		tz = loc.Location().String()
+	}
+	command := "/" + s.Command
+	if len(s.Args) > 0 {
+		command += " " + strings.Join(s.Args, " ")
+	}
+	return fmt.Sprintf("#%d %s %02d:%02d (%s): %s", s.ID, when, s.Hour, s.Minute, tz, command)
+}
+
+type scheduleStore struct {
+	mu     sync.Mutex
+	path   string
+	items  []schedule
+	nextID int
+}
+
+func loadScheduleStore(path string) (*scheduleStore, error) {
+	store := &scheduleStore{path: path, nextID: 1}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read schedules: %w", err)
+	}
+	if err := json.Unmarshal(raw, &store.items); err != nil {
+		return nil, fmt.Errorf("parse schedules: %w", err)
+	}
+	for _, item := range store.items {
+		if item.ID >= store.nextID {
+			store.nextID = item.ID + 1
+		}
+	}
+	return store, nil
+}
+
+func (s *scheduleStore) Add(item schedule) (schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item.ID = s.nextID
+	s.nextID++
+	s.items = append(s.items, item)
+	if err := s.saveLocked(); err != nil {
+		s.items = s.items[:len(s.items)-1]
+		return schedule{}, err
+	}
+	return item, nil
+}
+
+func (s *scheduleStore) Remove(chatID int64, id int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, item := range s.items {
+		if item.ID != id || item.ChatID != chatID {
+			continue
+		}
+		s.items = append(s.items[:i], s.items[i+1:]...)
+		return true, s.saveLocked()
+	}
+	return false, nil
+}
+
+func (s *scheduleStore) List(chatID int64) []schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []schedule
+	for _, item := range s.items {
+		if chatID == 0 || item.ChatID == chatID {
+			result = append(result, item)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+func (s *scheduleStore) saveLocked() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("create schedules dir: %w", err)
+	}
+	raw, err := json.MarshalIndent(s.items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal schedules: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write schedules: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("write schedules: %w", err)
+	}
+	return nil
+}
+
+// runScheduler раз в scheduleTick проверяет расписания и запускает
+// наступившие через обычные обработчики команд. Пропущенные во время
+// простоя запуски не догоняются.
+func (b *telegramBot) runScheduler() {
+	next := make(map[int]time.Time)
+	ticker := time.NewTicker(scheduleTick)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		active := make(map[int]struct{})
+		for _, item := range b.schedules.List(0) {
+			active[item.ID] = struct{}{}
+			due, err := scheduleDue(next, item, now)
+			if err != nil {
+				slog.Error("schedule failed", "schedule_id", item.ID, "chat_id", item.ChatID, "error", err)
+				continue
+			}
+			if due {
+				b.runScheduled(item)
+			}
+		}
+		for id := range next {
+			if _, ok := active[id]; !ok {
+				delete(next, id)
+			}
+		}
+	}
+}
+
+// scheduleDue сообщает, пора ли запускать item, и запоминает в next время
+// следующего запуска. Время считается заново, если с прошлой проверки
+// сменился пояс расписания, например чат поменял его через /tz.
+func scheduleDue(next map[int]time.Time, item schedule, now time.Time) (bool, error) {
+	loc, err := item.Location()
+	if err != nil {
+		return false, err
+	}
+	at, ok := next[item.ID]
+	if ok && at.Location().String() != loc.String() {
+		ok = false
+	}
+	if ok && now.Before(at) {
+		return false, nil
+	}
+	following, err := item.Next(now)
+	if err != nil {
+		return false, err
+	}
+	next[item.ID] = following
+	return ok, nil
+}
+
+func (b *telegramBot) runScheduled(item schedule) {
+	cmd, ok := b.commands.Lookup(item.Command)
+	if !ok {
+		b.sendError(item.ChatID, newLocalizedError("err.schedule_command", item.ID, item.Command))
+		return
+	}
+	// Расписания, созданные до появления списка отчётов, не запускают
+	// команды админа от имени автора.
+	if !cmd.Schedulable {
+		b.sendError(item.ChatID, newLocalizedError("err.command_unschedulable", cmd.Name))
+		return
+	}
+	b.runCommand(cmd, commandRequest{ChatID: item.ChatID, UserID: item.CreatedBy}, item.Args)
+}
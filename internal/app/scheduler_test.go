@@ -0,0 +1,170 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleArgs_Weekly(t *testing.T) {
+	args, err := parseScheduleArgs([]string{"weekly", "mon", "10:00", "UTC", "rating", "7d"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := args.Schedule
+	if s.Kind != scheduleWeekly || s.Weekday != int(time.Monday) || s.Hour != 10 || s.Minute != 0 {
+		t.Fatalf("unexpected schedule: %+v", s)
+	}
+	if s.Timezone != "UTC" || s.Command != "rating" || len(s.Args) != 1 || s.Args[0] != "7d" {
+		t.Fatalf("unexpected schedule: %+v", s)
+	}
+}
+
+func TestParseScheduleArgs_Daily(t *testing.T) {
+	args, err := parseScheduleArgs([]string{"daily", "21:30", "/stat"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Schedule.Kind != scheduleDaily || args.Schedule.Command != "stat" || args.Schedule.Timezone != "" {
+		t.Fatalf("unexpected schedule: %+v", args.Schedule)
+	}
+}
+
+func TestParseScheduleArgs_Invalid(t *testing.T) {
+	cases := [][]string{
+		nil,
+		{"daily", "10:00"},
+		{"hourly", "10:00", "stat"},
+		{"weekly", "xyz", "10:00", "stat"},
+		{"daily", "25:00", "stat"},
+		{"daily", "10:00", "Mars/Olympus", "stat"},
+	}
+	for _, raw := range cases {
+		if _, err := parseScheduleArgs(raw); err == nil {
+			t.Fatalf("expected error for %v", raw)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2024-05-01 — среда.
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	daily := schedule{Kind: scheduleDaily, Hour: 9, Minute: 15, Timezone: "UTC"}
+	next, err := daily.Next(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 5, 2, 9, 15, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("daily next=%v, want %v", next, want)
+	}
+
+	weekly := schedule{Kind: scheduleWeekly, Weekday: int(time.Monday), Hour: 10, Timezone: "UTC"}
+	next, err = weekly.Next(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 5, 6, 10, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("weekly next=%v, want %v", next, want)
+	}
+
+	// Тот же день недели, но время ещё не наступило.
+	sameDay := schedule{Kind: scheduleWeekly, Weekday: int(time.Wednesday), Hour: 18, Timezone: "UTC"}
+	next, _ = sameDay.Next(now)
+	if want := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("same day next=%v, want %v", next, want)
+	}
+}
+
+func TestScheduleNext_Timezone(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Moscow"); err != nil {
+		t.Skip("tzdata is not available")
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s := schedule{Kind: scheduleDaily, Hour: 10, Timezone: "Europe/Moscow"}
+	next, err := s.Next(now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 10:00 по Москве — 07:00 UTC следующего дня.
+	if want := time.Date(2024, 5, 2, 7, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Fatalf("next=%v, want %v", next, want)
+	}
+}
+
+func TestScheduleStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "schedules.json")
+	store, err := loadScheduleStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, err := store.Add(schedule{ChatID: 1, Kind: scheduleDaily, Command: "stat"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Add(schedule{ChatID: 2, Kind: scheduleDaily, Command: "rating"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reloaded, err := loadScheduleStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := reloaded.List(1); len(got) != 1 || got[0].ID != first.ID || got[0].Command != "stat" {
+		t.Fatalf("unexpected schedules for chat 1: %+v", got)
+	}
+	// Удалять можно только расписания своего чата.
+	if removed, _ := reloaded.Remove(2, first.ID); removed {
+		t.Fatal("schedule removed from another chat")
+	}
+	if removed, err := reloaded.Remove(1, first.ID); !removed || err != nil {
+		t.Fatalf("remove failed: %v %v", removed, err)
+	}
+	third, err := reloaded.Add(schedule{ChatID: 1, Kind: scheduleDaily, Command: "stat"})
+	if err != nil || third.ID != 3 {
+		t.Fatalf("ids must not be reused: %+v, %v", third, err)
+	}
+}
+
+func TestSchedulableCommands(t *testing.T) {
+	registry := defaultCommands()
+	for _, name := range []string{"stat", "rating", "synergy", "export", "last"} {
+		if cmd, _ := registry.Lookup(name); !cmd.Schedulable {
+			t.Fatalf("/%s should be schedulable", name)
+		}
+	}
+	// Команды админа и настройки чата в расписание не попадают.
+	for _, name := range []string{"schedule", "unschedule", "schedules", "backfill", "reload", "alias", "lang", "tz", "test"} {
+		if cmd, _ := registry.Lookup(name); cmd.Schedulable {
+			t.Fatalf("/%s should not be schedulable", name)
+		}
+	}
+}
+
+func TestScheduleDueFollowsChatTimezone(t *testing.T) {
+	if _, err := time.LoadLocation("Europe/Moscow"); err != nil {
+		t.Skip("tzdata is not available")
+	}
+	prefs := withChatPrefs(t)
+	if err := prefs.Load(filepath.Join(t.TempDir(), "chats.json")); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := prefs.Update(-100, func(s *chatSettings) { s.Timezone = "UTC" }); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	item := schedule{ID: 1, ChatID: -100, Kind: scheduleDaily, Hour: 10}
+	next := make(map[int]time.Time)
+	now := time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)
+	if due, err := scheduleDue(next, item, now); due || err != nil {
+		t.Fatalf("first check: due=%v err=%v", due, err)
+	}
+	// /tz Europe/Moscow: 10:00 по Москве — 07:00 UTC, а не 10:00 UTC.
+	if err := prefs.Update(-100, func(s *chatSettings) { s.Timezone = "Europe/Moscow" }); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if due, _ := scheduleDue(next, item, now.Add(time.Minute)); due {
+		t.Fatal("due right after timezone change")
+	}
+	if due, _ := scheduleDue(next, item, time.Date(2024, 5, 1, 7, 0, 30, 0, time.UTC)); !due {
+		t.Fatalf("not due at 10:00 Moscow, next=%v", next[1])
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// winnerTeam — название победившей команды.
+func winnerTeam(loc locale, details matchDetails) string {
+	if details.RadiantWin {
+		return loc.T("team.radiant")
+	}
+	return loc.T("team.dire")
+}
+
+func formatMatchScoreboardHeader(loc locale, details matchDetails) string {
+	start := loc.Time(details.StartTime).Format("2006-01-02 15:04")
+	lines := []string{
+		loc.T("scoreboard.title", details.MatchID),
+		loc.T("scoreboard.mode", escapeHTML(gameModeName(details.GameMode)), escapeHTML(lobbyTypeName(details.LobbyType))),
+		loc.T("scoreboard.time", start, formatDuration(details.Duration)),
+		loc.T("scoreboard.score", details.RadiantScore, details.DireScore, escapeHTML(winnerTeam(loc, details))),
+		fmt.Sprintf("<a href=\"https://www.opendota.com/matches/%d\">OpenDota</a>", details.MatchID),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// formatMatchScoreboard печатает обе команды; отслеживаемые игроки отмечены ★
+// и перечислены под таблицей.
+func formatMatchScoreboard(loc locale, details matchDetails, tracked []int64, heroes map[int]string) string {
+	trackedSet := make(map[int64]struct{}, len(tracked))
+	for _, id := range tracked {
+		trackedSet[id] = struct{}{}
+	}
+	var radiant, dire []matchDetailsPlayer
+	for _, player := range details.Players {
+		if player.PlayerSlot < 128 {
+			radiant = append(radiant, player)
+		} else {
+			dire = append(dire, player)
+		}
+	}
+
+	var builder strings.Builder
+	var marked []string
+	writeTeam := func(title string, won bool, score int, players []matchDetailsPlayer) {
+		mark := ""
+		if won {
+			mark = " 🏆"
+		}
+		builder.WriteString(fmt.Sprintf("%s (%d)%s\n", title, score, mark))
+		builder.WriteString(fmt.Sprintf("%-1s %-12s  %-8s  %-6s  %-7s  %-6s\n", "", loc.T("scoreboard.hero"), "K/D/A", "NW", "GPM/XPM", loc.T("scoreboard.damage")))
+		for _, player := range players {
+			heroName := heroes[player.HeroID]
+			if heroName == "" {
+				heroName = fmt.Sprintf("Hero #%d", player.HeroID)
+			}
+			star := " "
+			if _, ok := trackedSet[player.AccountID]; ok && player.AccountID != 0 {
+				star = "★"
+				marked = append(marked, fmt.Sprintf("%s — %s", displayName(player.AccountID, player.PersonaName), heroName))
+			}
+			kda := fmt.Sprintf("%d/%d/%d", player.Kills, player.Deaths, player.Assists)
+			builder.WriteString(fmt.Sprintf("%s %-12s  %-8s  %-6s  %-7s  %-6s\n",
+				star,
+				trimTo(heroName, 12),
+				kda,
+				formatThousands(player.NetWorth),
+				fmt.Sprintf("%d/%d", player.GPM, player.XPM),
+				formatThousands(player.HeroDamage),
+			))
+		}
+	}
+	writeTeam(loc.T("team.radiant"), details.RadiantWin, details.RadiantScore, radiant)
+	builder.WriteString("\n")
+	writeTeam(loc.T("team.dire"), !details.RadiantWin, details.DireScore, dire)
+	if len(marked) > 0 {
+		builder.WriteString("\n")
+		for _, line := range marked {
+			builder.WriteString("★ " + line + "\n")
+		}
+	}
+	return builder.String()
+}
+
+func formatThousands(value int) string {
+	if value < 1000 {
+		return fmt.Sprintf("%d", value)
+	}
+	return fmt.Sprintf("%.1fk", float64(value)/1000)
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatMatchScoreboard(t *testing.T) {
+	details := matchDetails{
+		MatchID:      42,
+		RadiantWin:   false,
+		RadiantScore: 10,
+		DireScore:    30,
+		Players: []matchDetailsPlayer{
+			{AccountID: 1, PersonaName: "Tracked", HeroID: 2, PlayerSlot: 0, Kills: 3, Deaths: 9, Assists: 4, NetWorth: 9500, GPM: 350, XPM: 400, HeroDamage: 12345},
+			{AccountID: 5, HeroID: 1, PlayerSlot: 128, Kills: 15, Deaths: 1, Assists: 7, NetWorth: 800},
+		},
+	}
+	out := formatMatchScoreboard(newLocale(langRU), details, []int64{1}, map[int]string{1: "Anti-Mage", 2: "Axe"})
+	// Победившая команда отмечена, отслеживаемый игрок выделен.
+	if !strings.Contains(out, "Dire (30) 🏆") || strings.Contains(out, "Radiant (10) 🏆") {
+		t.Fatalf("winner mark is wrong: %q", out)
+	}
+	if !strings.Contains(out, "★ Axe") || !strings.Contains(out, "★ Tracked — Axe") {
+		t.Fatalf("tracked player is not highlighted: %q", out)
+	}
+	if !strings.Contains(out, "9.5k") || !strings.Contains(out, "12.3k") || !strings.Contains(out, "350/400") {
+		t.Fatalf("missing stats: %q", out)
+	}
+	radiant := strings.Index(out, "Radiant")
+	antiMage := strings.Index(out, "Anti-Mage")
+	if antiMage < radiant || antiMage < strings.Index(out, "Dire") {
+		t.Fatalf("dire player must be listed in the Dire block: %q", out)
+	}
+}
+
+func TestFormatMatchScoreboardHeader(t *testing.T) {
+	out := formatMatchScoreboardHeader(newLocale(langRU), matchDetails{MatchID: 7, GameMode: 23, LobbyType: 7, RadiantWin: true})
+	for _, want := range []string{"Матч 7", "Turbo", "Ranked", "🏆 Radiant"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("header missing %q: %q", want, out)
+		}
+	}
+}
+
+func TestGameModeName_Unknown(t *testing.T) {
+	if got := gameModeName(999); got != "Mode #999" {
+		t.Fatalf("gameModeName(999)=%q", got)
+	}
+	if got := lobbyTypeName(999); got != "Lobby #999" {
+		t.Fatalf("lobbyTypeName(999)=%q", got)
+	}
+}
@@ -74,7 +74,7 @@ func trimTo(value string, max int) string {
 
 func fallbackName(name string) string {
 	if strings.TrimSpace(name) == "" {
-		return "неизвестный"
+		return defaultLocale().T("unknown")
 	}
 	return strings.TrimSpace(name)
 }
@@ -0,0 +1,178 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// playerURLHosts — сайты, у которых account_id идёт в пути после /players/.
+var playerURLHosts = map[string]string{
+	"opendota.com": "players",
+	"dotabuff.com": "players",
+	"stratz.com":   "players",
+}
+
+// parseSteamID переводит в 32-битный account_id любой распространённый
+// идентификатор: account_id, SteamID64, SteamID2 (STEAM_0:1:123), SteamID3
+// ([U:1:123]), ссылку steamcommunity.com/profiles/ и ссылки на профиль
+// OpenDota, Dotabuff и Stratz.
+func parseSteamID(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, newLocalizedError("err.steamid_empty")
+	}
+	var id int64
+	var err error
+	upper := strings.ToUpper(value)
+	switch {
+	case strings.HasPrefix(upper, "STEAM_"):
+		id, err = parseSteamID2(value)
+	case strings.HasPrefix(upper, "[U:") || strings.HasPrefix(upper, "U:"):
+		id, err = parseSteamID3(value)
+	case strings.Contains(value, "/"):
+		id, err = parseProfileURL(value)
+	default:
+		id, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			err = newLocalizedError("err.steamid_format", value)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if id > maxUint32 {
+		id -= steamID64Offset
+	}
+	if id <= 0 || id > maxUint32 {
+		return 0, newLocalizedError("err.steamid_range", id)
+	}
+	return id, nil
+}
+
+// parseSteamID2 разбирает STEAM_X:Y:Z, где account_id = Z*2 + Y.
+func parseSteamID2(value string) (int64, error) {
+	parts := strings.Split(value[len("STEAM_"):], ":")
+	if len(parts) != 3 {
+		return 0, newLocalizedError("err.steamid2", value)
+	}
+	y, errY := strconv.ParseInt(parts[1], 10, 64)
+	z, errZ := strconv.ParseInt(parts[2], 10, 64)
+	if errY != nil || errZ != nil || (y != 0 && y != 1) || z < 0 {
+		return 0, newLocalizedError("err.steamid2", value)
+	}
+	return z*2 + y, nil
+}
+
+// parseSteamID3 разбирает [U:1:N], где N — account_id.
+func parseSteamID3(value string) (int64, error) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	parts := strings.Split(inner, ":")
+	if len(parts) != 3 || !strings.EqualFold(parts[0], "U") {
+		return 0, newLocalizedError("err.steamid3", value)
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, newLocalizedError("err.steamid3", value)
+	}
+	return id, nil
+}
+
+func parseProfileURL(value string) (int64, error) {
+	raw := value
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return 0, newLocalizedError("err.steamid_url", value)
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	segments := strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' })
+	if host == "steamcommunity.com" {
+		if len(segments) >= 2 && segments[0] == "profiles" {
+			return parseSteamIDNumber(segments[1], value)
+		}
+		if len(segments) >= 1 && segments[0] == "id" {
+			return 0, newLocalizedError("err.steamid_vanity", value)
+		}
+		return 0, newLocalizedError("err.steamid_profiles", value)
+	}
+	section, known := playerURLHosts[host]
+	if !known {
+		return 0, newLocalizedError("err.steamid_host", value)
+	}
+	// Stratz пишет и /players/, и /player/.
+	if len(segments) >= 2 && (segments[0] == section || segments[0] == strings.TrimSuffix(section, "s")) {
+		return parseSteamIDNumber(segments[1], value)
+	}
+	return 0, newLocalizedError("err.steamid_section", section, value)
+}
+
+func parseSteamIDNumber(segment string, value string) (int64, error) {
+	id, err := strconv.ParseInt(segment, 10, 64)
+	if err != nil {
+		return 0, newLocalizedError("err.steamid_number", value)
+	}
+	return id, nil
+}
+
+// parseAccountList разбирает файл аккаунтов: на строке идентификатор в
+// любом формате parseSteamID, затем необязательный alias, а после # —
+// комментарий. Ошибки собираются со всех строк с номерами.
+func parseAccountList(source string, data string) ([]accountConfig, error) {
+	var accounts []accountConfig
+	var errs []error
+	seen := make(map[int64]int)
+	for i, line := range strings.Split(data, "\n") {
+		lineNo := i + 1
+		line = strings.TrimSpace(stripAccountComment(line))
+		if line == "" {
+			continue
+		}
+		value, alias := line, ""
+		if end := strings.IndexAny(line, " \t"); end >= 0 {
+			value, alias = line[:end], line[end+1:]
+		}
+		id, err := parseSteamID(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", source, lineNo, err))
+			continue
+		}
+		if first, ok := seen[id]; ok {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", source, lineNo, newLocalizedError("err.account_duplicate", id, first)))
+			continue
+		}
+		seen[id] = lineNo
+		accounts = append(accounts, accountConfig{ID: id, Alias: strings.TrimSpace(alias), Notify: true})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(accounts) == 0 {
+		return nil, newLocalizedError("err.accounts_empty", source)
+	}
+	return accounts, nil
+}
+
+// stripAccountComment отрезает комментарий: # в начале строки или после
+// пробела, чтобы не задеть якорь в ссылке.
+func stripAccountComment(line string) string {
+	for i, r := range line {
+		if r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func loadAccountFile(path string) ([]accountConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return parseAccountList(path, string(raw))
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSteamID(t *testing.T) {
+	cases := map[string]int64{
+		"123456789":          123456789,
+		"76561198083722517":  123456789,
+		"STEAM_0:1:61728394": 123456789,
+		"STEAM_1:1:61728394": 123456789,
+		"[U:1:123456789]":    123456789,
+		"U:1:123456789":      123456789,
+		"https://steamcommunity.com/profiles/76561198083722517/": 123456789,
+		"steamcommunity.com/profiles/76561198083722517":          123456789,
+		"https://www.opendota.com/players/123456789":             123456789,
+		"https://www.dotabuff.com/players/123456789/matches":     123456789,
+		"https://stratz.com/players/123456789":                   123456789,
+		"https://stratz.com/player/123456789?tab=heroes":         123456789,
+	}
+	for input, want := range cases {
+		got, err := parseSteamID(input)
+		if err != nil || got != want {
+			t.Fatalf("%q: got %d, err=%v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "abc", "STEAM_0:2:1", "[G:1:5]", "https://steamcommunity.com/id/vanity", "https://example.com/players/1", "-5", "0"} {
+		if _, err := parseSteamID(input); err == nil {
+			t.Fatalf("%q: expected error", input)
+		}
+	}
+	// Ошибка уходит в чат на его языке.
+	_, err := parseSteamID("https://steamcommunity.com/id/vanity")
+	if got := newLocale(langEN).ErrorText(err); !strings.HasPrefix(got, "steamcommunity.com/id/<name> links are not supported") {
+		t.Fatalf("en=%q", got)
+	}
+}
+
+func TestParseAccountList(t *testing.T) {
+	data := `# друзья
+123456789 Вася Пупкин   # мидер
+STEAM_0:0:5
+
+https://www.dotabuff.com/players/42#matches
+`
+	accounts, err := parseAccountList("account_id", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []accountConfig{
+		{ID: 123456789, Alias: "Вася Пупкин", Notify: true},
+		{ID: 10, Notify: true},
+		{ID: 42, Notify: true},
+	}
+	if len(accounts) != len(want) {
+		t.Fatalf("accounts=%+v", accounts)
+	}
+	for i := range want {
+		if accounts[i] != want[i] {
+			t.Fatalf("accounts[%d]=%+v, want %+v", i, accounts[i], want[i])
+		}
+	}
+}
+
+func TestParseAccountListReportsLines(t *testing.T) {
+	_, err := parseAccountList("account_id", "1\nfoo\n2\n[U:1:1]\n")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"account_id:2:", "account_id:4: аккаунт 1 уже указан в строке 1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q should contain %q", err.Error(), want)
+		}
+	}
+	if _, err := parseAccountList("account_id", "# пусто\n"); err == nil {
+		t.Fatal("expected error for empty list")
+	}
+}
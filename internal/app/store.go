@@ -0,0 +1,632 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localStore — локальная история: профили игроков, строки матчей по
+// игрокам и детали матчей. Данные лежат в каталоге сегментов NNNNNN.jsonl:
+// каждая строка — одна запись, поздние записи заменяют ранние. Новые записи
+// дописываются в последний сегмент; когда сегментов становится много, при
+// открытии они сжимаются в один. Пишет только один процесс — тот, кто
+// держит блокировку каталога (бот или мониторинг); остальные открывают
+// историю через OpenReadOnly. Пока хранилище не открыто, оно пустое и
+// ничего не пишет.
+type localStore struct {
+	mu        sync.RWMutex
+	dir       string
+	players   map[int64]storedPlayer
+	rows      map[int64]map[int64]recentMatch
+	matches   map[int64]matchDetails
+	backfills map[int64]backfillState
+	// synced — когда последние матчи игрока последний раз сошлись с
+	// сохранёнными; только в памяти.
+	synced   map[int64]time.Time
+	lock     *os.File
+	segment  *os.File
+	size     int64
+	segments []string
+}
+
+type storedPlayer struct {
+	AccountID   int64  `json:"account_id"`
+	PersonaName string `json:"personaname"`
+	AvatarFull  string `json:"avatarfull"`
+	RankTier    int    `json:"rank_tier"`
+	UpdatedAt   int64  `json:"updated_at"`
+}
+
+type storedRow struct {
+	AccountID int64 `json:"account_id"`
+	recentMatch
+}
+
+// backfillState — докуда дошла загрузка полной истории игрока. Offset —
+// сколько матчей от самого нового уже загружено; Complete — загружены все.
+type backfillState struct {
+	AccountID int64 `json:"account_id"`
+	Offset    int   `json:"offset"`
+	Complete  bool  `json:"complete"`
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// storeRecord — строка сегмента; заполнено ровно одно поле.
+type storeRecord struct {
+	Player   *storedPlayer  `json:"player,omitempty"`
+	Row      *storedRow     `json:"row,omitempty"`
+	Match    *matchDetails  `json:"match,omitempty"`
+	Backfill *backfillState `json:"backfill,omitempty"`
+}
+
+// storeStats — размеры хранилища для doctor.
+type storeStats struct {
+	Players  int
+	Rows     int
+	Matches  int
+	Segments int
+	Complete int
+}
+
+func newLocalStore() *localStore {
+	s := &localStore{}
+	s.resetLocked()
+	return s
+}
+
+func (s *localStore) resetLocked() {
+	s.players = make(map[int64]storedPlayer)
+	s.rows = make(map[int64]map[int64]recentMatch)
+	s.matches = make(map[int64]matchDetails)
+	s.backfills = make(map[int64]backfillState)
+	s.synced = make(map[int64]time.Time)
+}
+
+var errStoreLocked = errors.New("локальная история открыта на запись другим процессом (бот, monitor или backfill)")
+
+// Open блокирует каталог dir, читает сегменты и начинает дописывать в
+// последний. Если каталог уже заблокирован другим процессом, возвращает
+// errStoreLocked. Повторный Open с тем же каталогом ничего не делает.
+func (s *localStore) Open(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir == dir && s.segment != nil {
+		return nil
+	}
+	s.closeLocked()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create store dir: %w", err)
+	}
+	lock, err := lockStoreDir(dir)
+	if err != nil {
+		return err
+	}
+	s.lock = lock
+	if err := s.loadLocked(dir); err != nil {
+		s.closeLocked()
+		return err
+	}
+	segments := s.segments
+	if len(segments) >= storeCompactSegments {
+		return s.compactLocked()
+	}
+	if len(segments) == 0 {
+		return s.rotateLocked()
+	}
+	return s.openSegmentLocked(segments[len(segments)-1])
+}
+
+// OpenReadOnly читает сегменты из dir без блокировки и ничего не пишет:
+// ни сжатия, ни новых сегментов, Put* игнорируются. Так историю открывают
+// разовые команды и doctor, пока бот пишет в неё. Каталога может не быть.
+func (s *localStore) OpenReadOnly(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+	err := s.loadLocked(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		// Сегмент удалило сжатие в процессе-писателе — читаем заново.
+		err = s.loadLocked(dir)
+	}
+	if err != nil {
+		s.closeLocked()
+		return err
+	}
+	return nil
+}
+
+func (s *localStore) loadLocked(dir string) error {
+	segments, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return fmt.Errorf("list store segments: %w", err)
+	}
+	sort.Strings(segments)
+	s.dir = dir
+	s.resetLocked()
+	for _, path := range segments {
+		if err := s.replayLocked(path); err != nil {
+			return err
+		}
+	}
+	s.segments = segments
+	return nil
+}
+
+// Close закрывает текущий сегмент и снимает блокировку; после него
+// хранилище снова пустое.
+func (s *localStore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+	s.dir = ""
+	s.resetLocked()
+}
+
+func (s *localStore) closeLocked() {
+	if s.segment != nil {
+		s.segment.Close()
+		s.segment = nil
+	}
+	if s.lock != nil {
+		s.lock.Close()
+		s.lock = nil
+	}
+	s.segments = nil
+}
+
+// replayLocked применяет записи сегмента. Испорченные строки (например,
+// недописанная последняя после падения) пропускаются с предупреждением.
+func (s *localStore) replayLocked(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("read store segment: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), storeSegmentSize)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var record storeRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			slog.Warn("store record skipped", "segment", path, "line", lineNo, "error", err)
+			continue
+		}
+		s.applyLocked(record)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read store segment %s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *localStore) applyLocked(record storeRecord) {
+	switch {
+	case record.Player != nil:
+		s.players[record.Player.AccountID] = *record.Player
+	case record.Row != nil:
+		rows := s.rows[record.Row.AccountID]
+		if rows == nil {
+			rows = make(map[int64]recentMatch)
+			s.rows[record.Row.AccountID] = rows
+		}
+		rows[record.Row.MatchID] = record.Row.recentMatch
+	case record.Match != nil:
+		s.matches[record.Match.MatchID] = *record.Match
+	case record.Backfill != nil:
+		s.backfills[record.Backfill.AccountID] = *record.Backfill
+	}
+}
+
+func (s *localStore) openSegmentLocked(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open store segment: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open store segment: %w", err)
+	}
+	s.segment = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotateLocked начинает новый сегмент с номером больше последнего.
+func (s *localStore) rotateLocked() error {
+	if s.segment != nil {
+		s.segment.Close()
+		s.segment = nil
+	}
+	next := 1
+	if len(s.segments) > 0 {
+		var last int
+		fmt.Sscanf(filepath.Base(s.segments[len(s.segments)-1]), "%06d.jsonl", &last)
+		next = last + 1
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("%06d.jsonl", next))
+	if err := s.openSegmentLocked(path); err != nil {
+		return err
+	}
+	s.segments = append(s.segments, path)
+	return nil
+}
+
+// compactLocked переписывает текущее состояние в новый сегмент и удаляет
+// старые.
+func (s *localStore) compactLocked() error {
+	old := s.segments
+	if err := s.rotateLocked(); err != nil {
+		return err
+	}
+	for _, record := range s.snapshotLocked() {
+		if err := s.writeLocked(record); err != nil {
+			return err
+		}
+	}
+	for _, path := range old {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("remove store segment: %w", err)
+		}
+	}
+	s.segments = s.segments[len(old):]
+	return nil
+}
+
+func (s *localStore) snapshotLocked() []storeRecord {
+	var records []storeRecord
+	for _, player := range s.players {
+		player := player
+		records = append(records, storeRecord{Player: &player})
+	}
+	for accountID, rows := range s.rows {
+		for _, row := range rows {
+			records = append(records, storeRecord{Row: &storedRow{AccountID: accountID, recentMatch: row}})
+		}
+	}
+	for _, match := range s.matches {
+		match := match
+		records = append(records, storeRecord{Match: &match})
+	}
+	for _, state := range s.backfills {
+		state := state
+		records = append(records, storeRecord{Backfill: &state})
+	}
+	return records
+}
+
+// writeLocked дописывает запись и применяет её к памяти. Без открытого
+// сегмента запись только применяется.
+func (s *localStore) writeLocked(record storeRecord) error {
+	s.applyLocked(record)
+	if s.segment == nil {
+		return nil
+	}
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal store record: %w", err)
+	}
+	raw = append(raw, '\n')
+	if s.size > 0 && s.size+int64(len(raw)) > storeSegmentSize {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+	n, err := s.segment.Write(raw)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write store record: %w", err)
+	}
+	return nil
+}
+
+func (s *localStore) enabled() bool {
+	return s.segment != nil
+}
+
+// PutPlayer запоминает профиль, если он изменился.
+func (s *localStore) PutPlayer(accountID int64, profile playerProfileData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled() {
+		return
+	}
+	player := storedPlayer{
+		AccountID:   accountID,
+		PersonaName: profile.PersonaName,
+		AvatarFull:  profile.AvatarFull,
+		RankTier:    profile.RankTier,
+	}
+	if old, ok := s.players[accountID]; ok {
+		player.UpdatedAt = old.UpdatedAt
+		if old == player {
+			return
+		}
+	}
+	player.UpdatedAt = time.Now().Unix()
+	s.logWriteErr(s.writeLocked(storeRecord{Player: &player}))
+}
+
+// PutRows запоминает матчи игрока. Ответы OpenDota без GPM/XPM или линии не
+// стирают их у уже сохранённых строк.
+func (s *localStore) PutRows(accountID int64, matches []recentMatch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled() {
+		return
+	}
+	_, err := s.putRowsLocked(accountID, matches)
+	s.logWriteErr(err)
+}
+
+// PutRecent — PutRows для последних матчей игрока. Если история игрока
+// загружена полностью и ответ сошёлся с ней без пропусков, с этого момента
+// запросы по игроку можно отвечать локально (см. FreshMatches).
+func (s *localStore) PutRecent(accountID int64, matches []recentMatch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled() {
+		return
+	}
+	known, err := s.putRowsLocked(accountID, matches)
+	if err != nil {
+		s.logWriteErr(err)
+		return
+	}
+	if !s.backfills[accountID].Complete {
+		return
+	}
+	if known == 0 && len(matches) >= recentMatchesCount {
+		delete(s.synced, accountID)
+		slog.Warn("local history has a gap, run backfill", "account_id", accountID)
+		return
+	}
+	s.synced[accountID] = time.Now()
+}
+
+// putRowsLocked возвращает, сколько матчей из ответа уже было сохранено.
+func (s *localStore) putRowsLocked(accountID int64, matches []recentMatch) (int, error) {
+	known := 0
+	for _, m := range matches {
+		if m.MatchID == 0 || m.StartTime == 0 {
+			continue
+		}
+		row := m
+		if old, ok := s.rows[accountID][m.MatchID]; ok {
+			known++
+			if row.GPM == 0 && row.XPM == 0 {
+				row.GPM, row.XPM = old.GPM, old.XPM
+			}
+			if row.LaneRole == 0 && !row.IsRoaming {
+				row.LaneRole, row.IsRoaming = old.LaneRole, old.IsRoaming
+			}
+			if row == old {
+				continue
+			}
+		}
+		if err := s.writeLocked(storeRecord{Row: &storedRow{AccountID: accountID, recentMatch: row}}); err != nil {
+			return known, err
+		}
+	}
+	return known, nil
+}
+
+// Backfill возвращает состояние загрузки истории игрока.
+func (s *localStore) Backfill(accountID int64) backfillState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.backfills[accountID]
+	if !ok {
+		state.AccountID = accountID
+	}
+	return state
+}
+
+// PutBackfillPage сохраняет страницу истории и новое состояние загрузки.
+// Возвращает, сколько матчей страницы уже было сохранено. В отличие от
+// PutRows ошибки записи возвращаются: без них загрузку нельзя продолжить.
+func (s *localStore) PutBackfillPage(state backfillState, page []recentMatch) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled() {
+		return 0, fmt.Errorf("локальная история не открыта")
+	}
+	known, err := s.putRowsLocked(state.AccountID, page)
+	if err != nil {
+		return known, err
+	}
+	state.UpdatedAt = time.Now().Unix()
+	if err := s.writeLocked(storeRecord{Backfill: &state}); err != nil {
+		return known, err
+	}
+	if state.Complete {
+		s.synced[state.AccountID] = time.Now()
+	} else {
+		delete(s.synced, state.AccountID)
+	}
+	return known, nil
+}
+
+// PutMatch запоминает детали матча и строки тех его участников, которые
+// уже есть в хранилище.
+func (s *localStore) PutMatch(details matchDetails) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled() || details.MatchID == 0 || len(details.Players) == 0 {
+		return
+	}
+	if _, ok := s.matches[details.MatchID]; !ok {
+		if err := s.writeLocked(storeRecord{Match: &details}); err != nil {
+			s.logWriteErr(err)
+			return
+		}
+	}
+	for _, player := range details.Players {
+		if _, known := s.rows[player.AccountID]; !known {
+			continue
+		}
+		if _, ok := s.rows[player.AccountID][details.MatchID]; ok {
+			continue
+		}
+		row := matchRowFromDetails(details, player)
+		if err := s.writeLocked(storeRecord{Row: &storedRow{AccountID: player.AccountID, recentMatch: row}}); err != nil {
+			s.logWriteErr(err)
+			return
+		}
+	}
+}
+
+func (s *localStore) logWriteErr(err error) {
+	if err != nil {
+		slog.Warn("store write failed", "dir", s.dir, "error", err)
+	}
+}
+
+func matchRowFromDetails(details matchDetails, player matchDetailsPlayer) recentMatch {
+	return recentMatch{
+		MatchID:    details.MatchID,
+		HeroID:     player.HeroID,
+		Kills:      player.Kills,
+		Deaths:     player.Deaths,
+		Assists:    player.Assists,
+		Duration:   details.Duration,
+		StartTime:  details.StartTime,
+		PlayerSlot: player.PlayerSlot,
+		RadiantWin: details.RadiantWin,
+		GPM:        player.GPM,
+		XPM:        player.XPM,
+		GameMode:   details.GameMode,
+		LobbyType:  details.LobbyType,
+		LaneRole:   player.LaneRole,
+		IsRoaming:  player.IsRoaming,
+	}
+}
+
+func (s *localStore) Player(accountID int64) (playerProfileData, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	player, ok := s.players[accountID]
+	if !ok {
+		return playerProfileData{}, false
+	}
+	return playerProfileData{PersonaName: player.PersonaName, AvatarFull: player.AvatarFull, RankTier: player.RankTier}, true
+}
+
+func (s *localStore) Match(matchID int64) (matchDetails, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	details, ok := s.matches[matchID]
+	return details, ok
+}
+
+// Matches отбирает сохранённые матчи игрока по фильтру, от новых к старым.
+// false — об игроке ничего не известно.
+func (s *localStore) Matches(accountID int64, filter matchFilter, now time.Time) ([]recentMatch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rows, ok := s.rows[accountID]
+	if !ok {
+		return nil, false
+	}
+	return selectMatches(rows, filter, now, nil), true
+}
+
+// FreshMatches — Matches для игроков, чья история загружена полностью и
+// недавно сверена с OpenDota; для остальных false.
+func (s *localStore) FreshMatches(accountID int64, filter matchFilter, now time.Time) ([]recentMatch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.freshLocked(accountID, now) {
+		return nil, false
+	}
+	return selectMatches(s.rows[accountID], filter, now, nil), true
+}
+
+func (s *localStore) freshLocked(accountID int64, now time.Time) bool {
+	synced, ok := s.synced[accountID]
+	return ok && s.backfills[accountID].Complete && now.Sub(synced) <= storeSyncMaxAge
+}
+
+// MatchesWith — матчи accountID, где был (with = true) или не был otherID,
+// как included_account_id и excluded_account_id в OpenDota.
+func (s *localStore) MatchesWith(accountID int64, otherID int64, with bool, filter matchFilter, now time.Time) ([]playerMatch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.matchesWithLocked(accountID, otherID, with, filter, now)
+}
+
+// FreshMatchesWith — MatchesWith, когда свежая полная история есть у обоих.
+func (s *localStore) FreshMatchesWith(accountID int64, otherID int64, with bool, filter matchFilter, now time.Time) ([]playerMatch, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.freshLocked(accountID, now) || !s.freshLocked(otherID, now) {
+		return nil, false
+	}
+	return s.matchesWithLocked(accountID, otherID, with, filter, now)
+}
+
+func (s *localStore) matchesWithLocked(accountID int64, otherID int64, with bool, filter matchFilter, now time.Time) ([]playerMatch, bool) {
+	rows, ok := s.rows[accountID]
+	other, otherOK := s.rows[otherID]
+	if !ok || !otherOK {
+		return nil, false
+	}
+	selected := selectMatches(rows, filter, now, func(m recentMatch) bool {
+		_, together := other[m.MatchID]
+		return together == with
+	})
+	result := make([]playerMatch, 0, len(selected))
+	for _, m := range selected {
+		result = append(result, playerMatch{MatchID: m.MatchID, PlayerSlot: m.PlayerSlot, RadiantWin: m.RadiantWin})
+	}
+	return result, true
+}
+
+func selectMatches(rows map[int64]recentMatch, filter matchFilter, now time.Time, keep func(recentMatch) bool) []recentMatch {
+	result := make([]recentMatch, 0, len(rows))
+	for _, m := range rows {
+		if !filter.Allows(m, now) || (keep != nil && !keep(m)) {
+			continue
+		}
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StartTime == result[j].StartTime {
+			return result[i].MatchID > result[j].MatchID
+		}
+		return result[i].StartTime > result[j].StartTime
+	})
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[:filter.Limit]
+	}
+	return result
+}
+
+func (s *localStore) Stats() storeStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stats := storeStats{Players: len(s.players), Matches: len(s.matches), Segments: len(s.segments)}
+	for _, rows := range s.rows {
+		stats.Rows += len(rows)
+	}
+	for _, state := range s.backfills {
+		if state.Complete {
+			stats.Complete++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockStoreDir без flock только создаёт файл блокировки: на этих системах
+// не запускайте бота и backfill одновременно.
+func lockStoreDir(dir string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(dir, storeLockFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open store lock: %w", err)
+	}
+	return file, nil
+}
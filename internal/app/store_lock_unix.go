@@ -0,0 +1,29 @@
+//go:build unix
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockStoreDir берёт эксклюзивную блокировку каталога истории: писать в
+// сегменты может только один процесс. Блокировка снимается при закрытии
+// файла, в том числе когда процесс падает.
+func lockStoreDir(dir string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Join(dir, storeLockFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open store lock: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errStoreLocked
+		}
+		return nil, fmt.Errorf("lock store: %w", err)
+	}
+	return file, nil
+}
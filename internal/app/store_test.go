@@ -0,0 +1,192 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// withMatchHistory подменяет глобальную локальную историю открытой в
+// временном каталоге.
+func withMatchHistory(t *testing.T) (*localStore, string) {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "store")
+	store := newLocalStore()
+	if err := store.Open(dir); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	previous := matchHistory
+	matchHistory = store
+	t.Cleanup(func() {
+		matchHistory = previous
+		store.Close()
+	})
+	return store, dir
+}
+
+func TestLocalStoreReopen(t *testing.T) {
+	store, dir := withMatchHistory(t)
+	store.PutPlayer(1, playerProfileData{PersonaName: "Alpha", RankTier: 55})
+	store.PutRows(1, []recentMatch{
+		{MatchID: 10, StartTime: 1000, HeroID: 1, GPM: 500, XPM: 600},
+		{MatchID: 11, StartTime: 2000, HeroID: 2},
+	})
+	// Ответ без GPM/XPM не стирает сохранённые значения.
+	store.PutRows(1, []recentMatch{{MatchID: 10, StartTime: 1000, HeroID: 1, Kills: 3}})
+	store.PutMatch(matchDetails{MatchID: 11, StartTime: 2000, Players: []matchDetailsPlayer{{AccountID: 1}, {AccountID: 2}}})
+	store.Close()
+
+	reopened := newLocalStore()
+	if err := reopened.Open(dir); err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	if profile, ok := reopened.Player(1); !ok || profile.PersonaName != "Alpha" || profile.RankTier != 55 {
+		t.Fatalf("player=%+v, %v", profile, ok)
+	}
+	matches, ok := reopened.Matches(1, matchFilter{}, time.Unix(3000, 0))
+	if !ok || len(matches) != 2 || matches[0].MatchID != 11 {
+		t.Fatalf("matches=%+v", matches)
+	}
+	if got := matches[1]; got.Kills != 3 || got.GPM != 500 || got.XPM != 600 {
+		t.Fatalf("merged row=%+v", got)
+	}
+	if _, ok := reopened.Match(11); !ok {
+		t.Fatal("match 11 not stored")
+	}
+	// Игрок 2 не отслеживался, поэтому его строка из деталей не сохраняется.
+	if _, ok := reopened.Matches(2, matchFilter{}, time.Unix(3000, 0)); ok {
+		t.Fatal("unknown player has rows")
+	}
+}
+
+func TestLocalStoreFilter(t *testing.T) {
+	store, _ := withMatchHistory(t)
+	now := time.Unix(100*86400, 0)
+	ranked, turbo := 7, 23
+	store.PutRows(1, []recentMatch{
+		{MatchID: 1, StartTime: now.Add(-10 * 24 * time.Hour).Unix(), LobbyType: ranked},
+		{MatchID: 2, StartTime: now.Add(-2 * 24 * time.Hour).Unix(), LobbyType: ranked},
+		{MatchID: 3, StartTime: now.Add(-1 * 24 * time.Hour).Unix(), GameMode: turbo},
+		{MatchID: 4, StartTime: now.Add(-time.Hour).Unix(), LobbyType: ranked},
+	})
+	store.PutRows(2, []recentMatch{{MatchID: 2, StartTime: now.Add(-2 * 24 * time.Hour).Unix()}})
+
+	got, _ := store.Matches(1, matchFilter{Days: 7, LobbyType: &ranked}, now)
+	if len(got) != 2 || got[0].MatchID != 4 || got[1].MatchID != 2 {
+		t.Fatalf("ranked 7d=%+v", got)
+	}
+	got, _ = store.Matches(1, matchFilter{GameMode: &turbo}, now)
+	if len(got) != 1 || got[0].MatchID != 3 {
+		t.Fatalf("turbo=%+v", got)
+	}
+	got, _ = store.Matches(1, matchFilter{Limit: 3}, now)
+	if len(got) != 3 || got[2].MatchID != 2 {
+		t.Fatalf("limit=%+v", got)
+	}
+
+	with, ok := store.MatchesWith(1, 2, true, matchFilter{}, now)
+	if !ok || len(with) != 1 || with[0].MatchID != 2 {
+		t.Fatalf("with=%+v", with)
+	}
+	without, _ := store.MatchesWith(1, 2, false, matchFilter{}, now)
+	if len(without) != 3 {
+		t.Fatalf("without=%+v", without)
+	}
+	if _, ok := store.MatchesWith(1, 3, true, matchFilter{}, now); ok {
+		t.Fatal("unknown partner answered locally")
+	}
+}
+
+func TestLocalStoreCompaction(t *testing.T) {
+	dir := t.TempDir()
+	for i := 1; i <= storeCompactSegments; i++ {
+		line := fmt.Sprintf(`{"row":{"account_id":1,"match_id":%d,"start_time":%d}}`+"\n", i, i)
+		if i == storeCompactSegments {
+			// Недописанная после падения строка пропускается.
+			line += `{"row":{"account_id":1,`
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%06d.jsonl", i)), []byte(line), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	store := newLocalStore()
+	if err := store.Open(dir); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer store.Close()
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if len(segments) != 1 || filepath.Base(segments[0]) != fmt.Sprintf("%06d.jsonl", storeCompactSegments+1) {
+		t.Fatalf("segments=%v", segments)
+	}
+	if stats := store.Stats(); stats.Rows != storeCompactSegments || stats.Segments != 1 {
+		t.Fatalf("stats=%+v", stats)
+	}
+}
+
+func TestLocalStoreLock(t *testing.T) {
+	store, dir := withMatchHistory(t)
+	store.PutRows(1, []recentMatch{{MatchID: 1, StartTime: 1}})
+
+	// Второй писатель не открывает каталог, пока первый держит блокировку.
+	second := newLocalStore()
+	if err := second.Open(dir); !errors.Is(err, errStoreLocked) {
+		t.Fatalf("second open: %v", err)
+	}
+
+	reader := newLocalStore()
+	if err := reader.OpenReadOnly(dir); err != nil {
+		t.Fatalf("read-only open: %v", err)
+	}
+	defer reader.Close()
+	if stats := reader.Stats(); stats.Rows != 1 {
+		t.Fatalf("stats=%+v", stats)
+	}
+	reader.PutRows(1, []recentMatch{{MatchID: 2, StartTime: 2}})
+	store.Close()
+	if err := second.Open(dir); err != nil {
+		t.Fatalf("open after close: %v", err)
+	}
+	defer second.Close()
+	if stats := second.Stats(); stats.Rows != 1 {
+		t.Fatalf("read-only store wrote rows: %+v", stats)
+	}
+}
+
+func TestLocalStoreReadOnlyNoCompaction(t *testing.T) {
+	dir := t.TempDir()
+	for i := 1; i <= storeCompactSegments; i++ {
+		line := fmt.Sprintf(`{"row":{"account_id":1,"match_id":%d,"start_time":%d}}`+"\n", i, i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%06d.jsonl", i)), []byte(line), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	store := newLocalStore()
+	if err := store.OpenReadOnly(dir); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer store.Close()
+	segments, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if len(segments) != storeCompactSegments || store.Stats().Rows != storeCompactSegments {
+		t.Fatalf("segments=%v, stats=%+v", segments, store.Stats())
+	}
+	if _, err := os.Stat(filepath.Join(dir, storeLockFile)); !os.IsNotExist(err) {
+		t.Fatalf("read-only open created a lock file: %v", err)
+	}
+}
+
+func TestLocalMatchesFallback(t *testing.T) {
+	store, _ := withMatchHistory(t)
+	apiErr := errors.New("opendota: 502")
+	if _, err := localMatchesOr(1, matchFilter{}, apiErr); !errors.Is(err, apiErr) {
+		t.Fatalf("unknown player err=%v", err)
+	}
+	store.PutRows(1, []recentMatch{{MatchID: 5, StartTime: time.Now().Unix()}})
+	matches, err := localMatchesOr(1, matchFilter{Days: 1}, apiErr)
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("fallback=%+v, %v", matches, err)
+	}
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	synergySortWinrate = "winrate"
+	synergySortGames   = "games"
+)
+
+var synergySortAliases = map[string]string{
+	"wr":      synergySortWinrate,
+	"winrate": synergySortWinrate,
+	"games":   synergySortGames,
+	"игры":    synergySortGames,
+}
+
+type synergyPair struct {
+	A     int64
+	B     int64
+	Games int
+	Wins  int
+}
+
+func (p synergyPair) Winrate() float64 {
+	if p.Games == 0 {
+		return 0
+	}
+	return float64(p.Wins) * 100 / float64(p.Games)
+}
+
+// parseSynergyArgs разбирает фильтры матчей, порядок сортировки
+// (wr или games) и порог "min=N".
+func parseSynergyArgs(raw []string) (commandArgs, error) {
+	args := commandArgs{Raw: raw, Sort: synergySortWinrate, MinGames: synergyDefaultMinGames}
+	var filterTokens []string
+	for _, token := range raw {
+		lower := strings.ToLower(token)
+		if sortBy, ok := synergySortAliases[lower]; ok {
+			args.Sort = sortBy
+			continue
+		}
+		if value, ok := strings.CutPrefix(lower, "min="); ok {
+			minGames, err := strconv.Atoi(value)
+			if err != nil || minGames < 1 {
+				return commandArgs{}, newLocalizedError("err.min_games", token)
+			}
+			args.MinGames = minGames
+			continue
+		}
+		filterTokens = append(filterTokens, token)
+	}
+	filter, err := parseMatchFilter(filterTokens, synergyDefaultGames, synergyMaxGames)
+	if err != nil {
+		return commandArgs{}, err
+	}
+	args.Filter = filter
+	args.Limit = filter.Limit
+	return args, nil
+}
+
+// computeSynergy находит общие матчи каждой пары по match_id в уже
+// загруженных историях, поэтому на N игроков нужно N запросов, а не N².
+// Учитываются только игры в одной команде. Пары без общих игр тоже
+// возвращаются, чтобы матрица показывала, кто ни разу не играл вместе.
+func computeSynergy(accountIDs []int64, matchesByAccount map[int64][]recentMatch) []synergyPair {
+	type side struct {
+		radiant    bool
+		radiantWin bool
+	}
+	sides := make(map[int64]map[int64]side, len(accountIDs))
+	for _, id := range accountIDs {
+		byMatch := make(map[int64]side, len(matchesByAccount[id]))
+		for _, m := range matchesByAccount[id] {
+			byMatch[m.MatchID] = side{radiant: m.PlayerSlot < 128, radiantWin: m.RadiantWin}
+		}
+		sides[id] = byMatch
+	}
+	var pairs []synergyPair
+	for i, a := range accountIDs {
+		for _, b := range accountIDs[i+1:] {
+			pair := synergyPair{A: a, B: b}
+			for matchID, aSide := range sides[a] {
+				bSide, ok := sides[b][matchID]
+				if !ok || aSide.radiant != bSide.radiant {
+					continue
+				}
+				pair.Games++
+				if aSide.radiant == aSide.radiantWin {
+					pair.Wins++
+				}
+			}
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+func sortSynergy(pairs []synergyPair, sortBy string) {
+	sort.SliceStable(pairs, func(i, j int) bool {
+		wi, wj := pairs[i].Winrate(), pairs[j].Winrate()
+		if sortBy == synergySortGames {
+			if pairs[i].Games != pairs[j].Games {
+				return pairs[i].Games > pairs[j].Games
+			}
+			return wi > wj
+		}
+		if wi != wj {
+			return wi > wj
+		}
+		return pairs[i].Games > pairs[j].Games
+	})
+}
+
+// synergyOrder упорядочивает игроков для матрицы так же, как список пар:
+// по суммарному винрейту или числу игр в парах с минимум minGames общими
+// играми. Игроки без таких пар идут последними в порядке accountIDs.
+func synergyOrder(accountIDs []int64, pairs []synergyPair, sortBy string, minGames int) []int64 {
+	totals := make(map[int64]synergyPair, len(accountIDs))
+	for _, p := range pairs {
+		if p.Games == 0 || p.Games < minGames {
+			continue
+		}
+		for _, id := range []int64{p.A, p.B} {
+			total := totals[id]
+			total.Games += p.Games
+			total.Wins += p.Wins
+			totals[id] = total
+		}
+	}
+	order := append([]int64(nil), accountIDs...)
+	sort.SliceStable(order, func(i, j int) bool {
+		ti, tj := totals[order[i]], totals[order[j]]
+		if (ti.Games > 0) != (tj.Games > 0) {
+			return ti.Games > 0
+		}
+		wi, wj := ti.Winrate(), tj.Winrate()
+		if sortBy == synergySortGames {
+			if ti.Games != tj.Games {
+				return ti.Games > tj.Games
+			}
+			return wi > wj
+		}
+		if wi != wj {
+			return wi > wj
+		}
+		return ti.Games > tj.Games
+	})
+	return order
+}
+
+// formatSynergyMatrix печатает все пары матрицей: строки и столбцы —
+// игроки в порядке synergyOrder, в ячейке винрейт и число общих игр или
+// "-", если игр меньше minGames. Столбцы подписаны номерами строк, чтобы
+// таблица влезала.
+func formatSynergyMatrix(loc locale, accountIDs []int64, pairs []synergyPair, names map[int64]string, sortBy string, minGames int) string {
+	byPair := make(map[[2]int64]synergyPair, len(pairs)*2)
+	for _, p := range pairs {
+		byPair[[2]int64{p.A, p.B}] = p
+		byPair[[2]int64{p.B, p.A}] = p
+	}
+	order := synergyOrder(accountIDs, pairs, sortBy, minGames)
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%-2s  %-12s", "#", loc.T("synergy.player")))
+	for i := range order {
+		builder.WriteString(fmt.Sprintf("  %8d", i+1))
+	}
+	builder.WriteString("\n")
+	for i, a := range order {
+		builder.WriteString(fmt.Sprintf("%-2d  %-12s", i+1, trimTo(names[a], 12)))
+		for _, b := range order {
+			cell := "-"
+			if a == b {
+				cell = "·"
+			} else if p := byPair[[2]int64{a, b}]; p.Games > 0 && p.Games >= minGames {
+				cell = fmt.Sprintf("%.0f%%/%d", p.Winrate(), p.Games)
+			}
+			builder.WriteString(fmt.Sprintf("  %8s", cell))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// formatSynergyTable печатает матрицу всех пар, а под ней — пары с
+// минимум minGames общими играми; pairs уже отсортированы по sortBy.
+func formatSynergyTable(loc locale, accountIDs []int64, pairs []synergyPair, names map[int64]string, sortBy string, minGames int) string {
+	var builder strings.Builder
+	builder.WriteString(formatSynergyMatrix(loc, accountIDs, pairs, names, sortBy, minGames))
+	builder.WriteString("\n")
+	builder.WriteString(fmt.Sprintf("%-16s  %-16s  %-5s  %-7s\n", loc.T("synergy.player"), loc.T("synergy.partner"), loc.T("synergy.games"), "Winrate"))
+	hidden, played := 0, 0
+	for _, p := range pairs {
+		if p.Games > 0 {
+			played++
+		}
+		if p.Games < minGames {
+			hidden++
+			continue
+		}
+		builder.WriteString(fmt.Sprintf("%-16s  %-16s  %-5d  %6.1f%%\n", trimTo(names[p.A], 16), trimTo(names[p.B], 16), p.Games, p.Winrate()))
+	}
+	if hidden > 0 {
+		builder.WriteString("\n" + loc.T("synergy.hidden", loc.N(minGames, "games.ins"), hidden) + "\n")
+	}
+	if played == 0 {
+		builder.WriteString(loc.T("synergy.none") + "\n")
+	}
+	return builder.String()
+}
+
+func buildSynergyTable(loc locale, accountIDs []int64, filter matchFilter, sortBy string, minGames int) (string, error) {
+	matchesByAccount := make(map[int64][]recentMatch, len(accountIDs))
+	for _, id := range accountIDs {
+		matches, err := fetchFilteredMatches(id, filter)
+		if err != nil {
+			return "", err
+		}
+		matchesByAccount[id] = matches
+	}
+	names := make(map[int64]string, len(accountIDs))
+	for _, player := range loadTrackedPlayers(accountIDs) {
+		names[player.AccountID] = player.Name
+	}
+	pairs := computeSynergy(accountIDs, matchesByAccount)
+	sortSynergy(pairs, sortBy)
+	return formatSynergyTable(loc, accountIDs, pairs, names, sortBy, minGames), nil
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSynergyArgs(t *testing.T) {
+	args, err := parseSynergyArgs([]string{"30d", "games", "min=5", "ranked"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Sort != synergySortGames || args.MinGames != 5 {
+		t.Fatalf("sort=%q min=%d", args.Sort, args.MinGames)
+	}
+	if args.Filter.Days != 30 || args.Filter.Queue != "ranked" {
+		t.Fatalf("unexpected filter: %+v", args.Filter)
+	}
+
+	args, err = parseSynergyArgs(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args.Sort != synergySortWinrate || args.MinGames != synergyDefaultMinGames || args.Filter.Limit != synergyDefaultGames {
+		t.Fatalf("unexpected defaults: %+v", args)
+	}
+
+	if _, err := parseSynergyArgs([]string{"min=0"}); err == nil {
+		t.Fatal("expected error for min=0")
+	}
+}
+
+func TestComputeSynergy(t *testing.T) {
+	// Матч 1: все трое за Radiant, победа. Матч 2: 1 и 2 за Dire, поражение,
+	// 3 против них. Матч 3 есть только у игрока 1.
+	matches := map[int64][]recentMatch{
+		1: {{MatchID: 1, PlayerSlot: 0, RadiantWin: true}, {MatchID: 2, PlayerSlot: 128, RadiantWin: true}, {MatchID: 3}},
+		2: {{MatchID: 1, PlayerSlot: 1, RadiantWin: true}, {MatchID: 2, PlayerSlot: 129, RadiantWin: true}},
+		3: {{MatchID: 1, PlayerSlot: 2, RadiantWin: true}, {MatchID: 2, PlayerSlot: 3, RadiantWin: true}},
+	}
+	pairs := computeSynergy([]int64{1, 2, 3}, matches)
+	got := make(map[[2]int64]synergyPair)
+	for _, p := range pairs {
+		got[[2]int64{p.A, p.B}] = p
+	}
+	if p := got[[2]int64{1, 2}]; p.Games != 2 || p.Wins != 1 {
+		t.Fatalf("pair 1-2: %+v", p)
+	}
+	if p := got[[2]int64{1, 3}]; p.Games != 1 || p.Wins != 1 {
+		t.Fatalf("pair 1-3: %+v", p)
+	}
+	if p := got[[2]int64{2, 3}]; p.Games != 1 || p.Wins != 1 {
+		t.Fatalf("pair 2-3: %+v", p)
+	}
+}
+
+func TestSortAndFormatSynergy(t *testing.T) {
+	pairs := []synergyPair{
+		{A: 1, B: 2, Games: 10, Wins: 5},
+		{A: 1, B: 3, Games: 4, Wins: 3},
+		{A: 2, B: 3, Games: 1, Wins: 1},
+	}
+	sortSynergy(pairs, synergySortGames)
+	if pairs[0].B != 2 {
+		t.Fatalf("games sort: %+v", pairs)
+	}
+	sortSynergy(pairs, synergySortWinrate)
+	if pairs[0].A != 2 || pairs[1].B != 3 {
+		t.Fatalf("winrate sort: %+v", pairs)
+	}
+	names := map[int64]string{1: "Alpha", 2: "Bravo", 3: "Charlie"}
+	table := formatSynergyTable(newLocale(langRU), []int64{1, 2, 3}, pairs, names, synergySortWinrate, 2)
+	if strings.Contains(table, "Bravo             Charlie") {
+		t.Fatalf("pair below threshold is shown:\n%s", table)
+	}
+	if !strings.Contains(table, "Скрыто пар с менее чем 2 играми: 1") {
+		t.Fatalf("missing hidden note:\n%s", table)
+	}
+}
+
+func TestSynergyMatrixShowsEveryPair(t *testing.T) {
+	matches := map[int64][]recentMatch{
+		1: {{MatchID: 1, PlayerSlot: 0, RadiantWin: true}},
+		2: {{MatchID: 1, PlayerSlot: 1, RadiantWin: true}},
+		3: {{MatchID: 2, PlayerSlot: 0, RadiantWin: true}},
+	}
+	pairs := computeSynergy([]int64{1, 2, 3}, matches)
+	if len(pairs) != 3 {
+		t.Fatalf("pairs=%+v", pairs)
+	}
+	names := map[int64]string{1: "Alpha", 2: "Bravo", 3: "Charlie"}
+	lines := strings.Split(formatSynergyMatrix(newLocale(langRU), []int64{1, 2, 3}, pairs, names, synergySortWinrate, 1), "\n")
+	// Charlie ни с кем не играл: в его строке только прочерки.
+	if !strings.Contains(lines[1], "100%/1") || !strings.Contains(lines[3], "-         -         ·") {
+		t.Fatalf("matrix:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestSynergyMatrixThresholdAndOrder(t *testing.T) {
+	pairs := []synergyPair{
+		{A: 1, B: 2, Games: 1, Wins: 1},
+		{A: 1, B: 3, Games: 5, Wins: 2},
+		{A: 2, B: 3, Games: 4, Wins: 3},
+	}
+	names := map[int64]string{1: "Alpha", 2: "Bravo", 3: "Charlie"}
+	lines := strings.Split(formatSynergyMatrix(newLocale(langRU), []int64{1, 2, 3}, pairs, names, synergySortWinrate, 2), "\n")
+	// Пара с одной игрой ниже порога — прочерк вместо 100%/1.
+	if strings.Contains(strings.Join(lines, "\n"), "100%/1") {
+		t.Fatalf("noise cell shown:\n%s", strings.Join(lines, "\n"))
+	}
+	// По винрейту: Bravo 3/4, Charlie 5/9, Alpha 2/5.
+	for i, name := range []string{"Bravo", "Charlie", "Alpha"} {
+		if !strings.Contains(lines[i+1], name) {
+			t.Fatalf("row %d should be %s:\n%s", i+1, name, strings.Join(lines, "\n"))
+		}
+	}
+	// По числу игр: Charlie 9, Alpha 5, Bravo 4.
+	if order := synergyOrder([]int64{1, 2, 3}, pairs, synergySortGames, 2); order[0] != 3 || order[1] != 1 || order[2] != 2 {
+		t.Fatalf("games order=%v", order)
+	}
+}
@@ -3,12 +3,16 @@ package app
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
-	"os"
+	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,12 +20,22 @@ type telegramUpdate struct {
 	UpdateID      int                    `json:"update_id"`
 	Message       *telegramMessage       `json:"message"`
 	CallbackQuery *telegramCallbackQuery `json:"callback_query"`
+	InlineQuery   *telegramInlineQuery   `json:"inline_query"`
 }
 
 type telegramMessage struct {
-	MessageID int          `json:"message_id"`
-	Chat      telegramChat `json:"chat"`
-	Text      string       `json:"text"`
+	MessageID int           `json:"message_id"`
+	From      *telegramUser `json:"from"`
+	Chat      telegramChat  `json:"chat"`
+	Text      string        `json:"text"`
+	Caption   string        `json:"caption"`
+	Photo     []any         `json:"photo"`
+}
+
+type telegramUser struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
 }
 
 type telegramChat struct {
@@ -34,142 +48,249 @@ type telegramCallbackQuery struct {
 	Message *telegramMessage `json:"message"`
 }
 
+type telegramInlineQuery struct {
+	ID    string        `json:"id"`
+	From  *telegramUser `json:"from"`
+	Query string        `json:"query"`
+}
+
 type telegramUpdatesResponse struct {
 	OK          bool             `json:"ok"`
 	Result      []telegramUpdate `json:"result"`
 	Description string           `json:"description"`
 }
 
-func runTelegramBot(token string, accountStore *accountIDStore, heroes map[int]string) error {
-	apiBase := fmt.Sprintf(telegramBaseURL, token)
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+}
+
+type telegramBot struct {
+	apiBase      string
+	username     string
+	accountStore *accountIDStore
+	heroes       map[int]string
+	commands     *commandRegistry
+	admins       map[int64]struct{}
+	schedules    *scheduleStore
+	ratings      *ratingHistory
+	// backfilling — идёт /backfill; второй запуск ждёт окончания первого.
+	backfilling atomic.Bool
+}
+
+func newTelegramBot(cfg config, accountStore *accountIDStore, heroes map[int]string) (*telegramBot, error) {
+	bot := &telegramBot{
+		apiBase:      fmt.Sprintf(telegramBaseURL, cfg.Telegram.Token),
+		accountStore: accountStore,
+		heroes:       heroes,
+		commands:     defaultCommands(),
+		admins:       cfg.AdminIDs(),
+		ratings:      newRatingHistory(),
+	}
+	schedules, err := loadScheduleStore(cfg.SchedulesPath())
+	if err != nil {
+		return nil, err
+	}
+	bot.schedules = schedules
+	var me telegramUser
+	if err := callTelegram(bot.apiBase, "getMe", map[string]any{}, &me); err != nil {
+		return nil, err
+	}
+	bot.username = me.Username
+	return bot, nil
+}
+
+func runTelegramBot(cfg config, accountStore *accountIDStore, heroes map[int]string) error {
+	bot, err := newTelegramBot(cfg, accountStore, heroes)
+	if err != nil {
+		return err
+	}
+	if err := bot.publishCommands(); err != nil {
+		slog.Warn("telegram setMyCommands failed", "error", err)
+	}
+	go bot.runScheduler()
+	go watchAccountStore(accountStore, accountsWatchInterval, bot.announceRoster)
+	slog.Info("telegram bot started", "username", bot.username, "accounts", len(accountStore.Get()))
 	offset := 0
+	backoff := telegramRetryMin
 	for {
-		url := fmt.Sprintf("%s/getUpdates?timeout=30&offset=%d", apiBase, offset)
+		url := fmt.Sprintf("%s/getUpdates?timeout=30&offset=%d", bot.apiBase, offset)
 		var resp telegramUpdatesResponse
 		if err := getJSON(url, &resp, nil); err != nil {
-			time.Sleep(2 * time.Second)
+			slog.Warn("telegram getUpdates failed", "error", err, "retry_in", backoff)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, telegramRetryMax)
 			continue
 		}
+		backoff = telegramRetryMin
 		if !resp.OK {
 			return fmt.Errorf("telegram getUpdates failed: %s", resp.Description)
 		}
 		for _, upd := range resp.Result {
 			offset = upd.UpdateID + 1
 			if upd.CallbackQuery != nil {
-				if err := handleTelegramCallback(apiBase, upd.CallbackQuery, heroes); err != nil {
-					return err
-				}
+				start := time.Now()
+				err := bot.handleCallback(upd.CallbackQuery)
+				logHandled("callback", err, start, "data", upd.CallbackQuery.Data)
 				continue
 			}
-			if upd.Message == nil {
+			if upd.InlineQuery != nil {
+				// Inline-запрос приходит на каждое нажатие клавиши, поэтому
+				// отвечаем не в основном цикле, чтобы не задерживать команды.
+				go func(query *telegramInlineQuery) {
+					start := time.Now()
+					err := bot.handleInlineQuery(query)
+					logHandled("inline query", err, start, "query", query.Query)
+				}(upd.InlineQuery)
 				continue
 			}
-			text := strings.TrimSpace(upd.Message.Text)
-			if isStatCommand(text) {
-				accountIDs := accountStore.Get()
-				for _, accountID := range accountIDs {
-					player, err := fetchPlayerProfile(accountID)
-					if err != nil {
-						sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
-						continue
-					}
-					matches, err := fetchRecentMatches(accountID)
-					if err != nil {
-						sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
-						continue
-					}
-					winrate := calcWinrate(matches, 20)
-					if len(matches) > 10 {
-						matches = matches[:10]
-					}
-					table := buildPlayerTable(matches, heroes, player.PersonaName)
-					name := player.PersonaName
-					if name == "" {
-						name = "неизвестный"
-					}
-					header := fmt.Sprintf("<b>Последние матчи (%s)</b>\n<b>Winrate (за 20 игр): %.1f%%</b>\n<b>✅ победа, ❌ поражение</b>\n", escapeHTML(name), winrate)
-					if player.AvatarFull != "" {
-						if err := sendTelegramPhoto(apiBase, upd.Message.Chat.ID, player.AvatarFull, header, "HTML", nil); err != nil {
-							return err
-						}
-						for _, msg := range buildTelegramMessages(table, "") {
-							if err := sendTelegramMessage(apiBase, upd.Message.Chat.ID, msg, "HTML", nil); err != nil {
-								return err
-							}
-						}
-					} else {
-						for _, msg := range buildTelegramMessages(table, header) {
-							if err := sendTelegramMessage(apiBase, upd.Message.Chat.ID, msg, "HTML", nil); err != nil {
-								return err
-							}
-						}
-					}
-				}
-				continue
-			}
-			if isRatingCommand(text) {
-				table, err := buildRatingTable(accountStore.Get())
-				if err != nil {
-					sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
-					continue
-				}
-				header := "<b>Рейтинг по Winrate (50)</b>\n"
-				for _, msg := range buildTelegramMessages(table, header) {
-					if err := sendTelegramMessage(apiBase, upd.Message.Chat.ID, msg, "HTML", nil); err != nil {
-						return err
-					}
-				}
-				continue
-			}
-			if ok, limit, err := parseFriendsCommand(text); ok {
-				if err != nil {
-					sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
-					continue
-				}
-				table, err := buildBestFriendsTable(accountStore.Get(), limit)
-				if err != nil {
-					sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
-					continue
-				}
-				header := fmt.Sprintf("<b>Лучшие напарники по Winrate (за последние %d игр)</b>\n", limit)
-				for _, msg := range buildTelegramMessages(table, header) {
-					if err := sendTelegramMessage(apiBase, upd.Message.Chat.ID, msg, "HTML", nil); err != nil {
-						return err
-					}
-				}
-				continue
-			}
-			if isChatIDCommand(text) {
-				if err := sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("chat_id: %d", upd.Message.Chat.ID), "", nil); err != nil {
-					return err
-				}
-				continue
-			}
-			if isTestCommand(text) {
-				msg, err := buildTestMatchSummary(accountStore.Get(), heroes)
-				if err != nil {
-					sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
-					continue
-				}
-				if err := sendTelegramMessage(apiBase, upd.Message.Chat.ID, msg.Text, "", buildMatchDetailsMarkup(msg)); err != nil {
-					return err
-				}
-				continue
-			}
-			if isReloadAccsCommand(text) {
-				ids, err := loadAccountIDs("account_id")
-				if err != nil {
-					sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("Ошибка reload: %s", err.Error()), "", nil)
-					continue
-				}
-				accountStore.Set(ids)
-				if err := sendTelegramMessage(apiBase, upd.Message.Chat.ID, fmt.Sprintf("account_id обновлён: %d аккаунтов", len(ids)), "", nil); err != nil {
-					return err
-				}
+			if upd.Message == nil {
 				continue
 			}
+			bot.handleMessage(upd.Message)
+		}
+	}
+}
+
+func (b *telegramBot) handleMessage(msg *telegramMessage) {
+	name, raw, ok := parseCommand(strings.TrimSpace(msg.Text), b.username)
+	if !ok {
+		return
+	}
+	cmd, ok := b.commands.Lookup(name)
+	if !ok {
+		return
+	}
+	req := commandRequest{ChatID: msg.Chat.ID}
+	if msg.From != nil {
+		req.UserID = msg.From.ID
+	}
+	b.runCommand(cmd, req, raw)
+}
+
+// runCommand выполняет команду на языке чата и пишет в лог её вызов и
+// длительность.
+func (b *telegramBot) runCommand(cmd botCommand, req commandRequest, raw []string) {
+	start := time.Now()
+	req.Locale = localeFor(req.ChatID)
+	err := b.executeCommand(cmd, req, raw)
+	logHandled("command", err, start, "command", cmd.Name, "args", strings.Join(raw, " "),
+		"chat_id", req.ChatID, "user_id", req.UserID)
+	if err != nil {
+		b.sendError(req.ChatID, err)
+	}
+}
+
+func (b *telegramBot) executeCommand(cmd botCommand, req commandRequest, raw []string) error {
+	if cmd.Permission == permissionAdmin && !b.isAdmin(req) {
+		return errors.New(req.Locale.T("admin_only", cmd.Name))
+	}
+	args, err := cmd.Args(raw)
+	if err != nil {
+		return err
+	}
+	req.Args = args
+	return cmd.Handle(b, req)
+}
+
+// logHandled пишет обработку апдейта: info при успехе, warn с ошибкой.
+func logHandled(kind string, err error, start time.Time, attrs ...any) {
+	attrs = append(attrs, "duration", time.Since(start))
+	if err != nil {
+		slog.Warn(kind+" failed", append(attrs, "error", err)...)
+		return
+	}
+	slog.Info(kind, attrs...)
+}
+
+// withoutURL убирает адрес запроса из ошибки http.Client: в адресе Telegram
+// API есть токен бота, а ошибки уходят в лог как есть.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", strings.ToLower(urlErr.Op), urlErr.Err)
+	}
+	return err
+}
+
+func (b *telegramBot) isAdmin(req commandRequest) bool {
+	if _, ok := b.admins[req.ChatID]; ok {
+		return true
+	}
+	if req.UserID == 0 {
+		return false
+	}
+	_, ok := b.admins[req.UserID]
+	return ok
+}
+
+// publishCommands публикует меню команд на языке из конфига и отдельно
+// для каждого языка каталога — Telegram покажет его по языку клиента.
+func (b *telegramBot) publishCommands() error {
+	payload := map[string]any{
+		"commands": b.commands.telegramCommandList(defaultLocale()),
+	}
+	if err := callTelegram(b.apiBase, "setMyCommands", payload, nil); err != nil {
+		return err
+	}
+	for _, lang := range supportedLangs() {
+		payload := map[string]any{
+			"commands":      b.commands.telegramCommandList(newLocale(lang)),
+			"language_code": lang,
+		}
+		if err := callTelegram(b.apiBase, "setMyCommands", payload, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *telegramBot) sendError(chatID int64, err error) {
+	loc := localeFor(chatID)
+	if sendErr := sendTelegramMessage(b.apiBase, chatID, loc.T("error", loc.ErrorText(err)), "", nil); sendErr != nil {
+		slog.Error("telegram send failed", "chat_id", chatID, "error", sendErr)
+	}
+}
+
+func (b *telegramBot) sendTable(chatID int64, header string, table string) error {
+	for _, msg := range buildTelegramMessages(table, header) {
+		if err := sendTelegramMessage(b.apiBase, chatID, msg, "HTML", nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func callTelegram(apiBase string, method string, payload any, out any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal telegram %s: %w", method, err)
+	}
+	req, err := http.NewRequest(http.MethodPost, apiBase+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram %s: %w", method, withoutURL(err))
+	}
+	defer resp.Body.Close()
+	var result telegramResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return fmt.Errorf("telegram %s failed: %s", method, resp.Status)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram %s failed: %s", method, result.Description)
+	}
+	if out != nil {
+		if err := json.Unmarshal(result.Result, out); err != nil {
+			return fmt.Errorf("decode telegram %s: %w", method, err)
 		}
 	}
+	return nil
 }
 
 func sendTelegramMessage(apiBase string, chatID int64, text string, parseMode string, replyMarkup any) error {
@@ -195,7 +316,7 @@ func sendTelegramMessage(apiBase string, chatID int64, text string, parseMode st
 	client := &http.Client{Timeout: requestTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("telegram send: %w", err)
+		return fmt.Errorf("telegram send: %w", withoutURL(err))
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -231,7 +352,7 @@ func sendTelegramPhoto(apiBase string, chatID int64, photoURL string, caption st
 	client := &http.Client{Timeout: requestTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("telegram photo send: %w", err)
+		return fmt.Errorf("telegram photo send: %w", withoutURL(err))
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -241,6 +362,84 @@ func sendTelegramPhoto(apiBase string, chatID int64, photoURL string, caption st
 	return nil
 }
 
+func sendTelegramPhotoFile(apiBase string, chatID int64, filename string, data []byte, caption string, parseMode string, replyMarkup any) error {
+	return sendTelegramFile(apiBase, "sendPhoto", "photo", chatID, filename, data, caption, parseMode, replyMarkup)
+}
+
+func sendTelegramDocument(apiBase string, chatID int64, filename string, data []byte, caption string, parseMode string) error {
+	return sendTelegramFile(apiBase, "sendDocument", "document", chatID, filename, data, caption, parseMode, nil)
+}
+
+// sendTelegramFile загружает файл через multipart/form-data в поле field
+// метода method (sendPhoto, sendDocument).
+func sendTelegramFile(apiBase string, method string, field string, chatID int64, filename string, data []byte, caption string, parseMode string, replyMarkup any) error {
+	return postTelegramFile(apiBase, method, field, chatID, filename, data, caption, parseMode, replyMarkup, nil)
+}
+
+// postTelegramFile — sendTelegramFile, который с непустым out разбирает
+// в него отправленное сообщение.
+func postTelegramFile(apiBase string, method string, field string, chatID int64, filename string, data []byte, caption string, parseMode string, replyMarkup any, out any) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	fields := map[string]string{
+		"chat_id": strconv.FormatInt(chatID, 10),
+	}
+	if caption != "" {
+		fields["caption"] = caption
+	}
+	if parseMode != "" {
+		fields["parse_mode"] = parseMode
+	}
+	if replyMarkup != nil {
+		markup, err := json.Marshal(replyMarkup)
+		if err != nil {
+			return fmt.Errorf("marshal telegram %s markup: %w", method, err)
+		}
+		fields["reply_markup"] = string(markup)
+	}
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return fmt.Errorf("telegram %s form: %w", method, err)
+		}
+	}
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		return fmt.Errorf("telegram %s form: %w", method, err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("telegram %s form: %w", method, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("telegram %s form: %w", method, err)
+	}
+	req, err := http.NewRequest(http.MethodPost, apiBase+"/"+method, &body)
+	if err != nil {
+		return fmt.Errorf("telegram %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram %s send: %w", method, withoutURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
+		return fmt.Errorf("telegram %s failed: %s: %s", method, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if out == nil {
+		return nil
+	}
+	var result telegramResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return fmt.Errorf("decode telegram %s: %w", method, err)
+	}
+	if err := json.Unmarshal(result.Result, out); err != nil {
+		return fmt.Errorf("decode telegram %s: %w", method, err)
+	}
+	return nil
+}
+
 func splitText(text string, maxLen int) []string {
 	runes := []rune(text)
 	if len(runes) <= maxLen {
@@ -309,82 +508,198 @@ func runeLen(value string) int {
 	return len([]rune(value))
 }
 
-func telegramNotifier(token string) func(matchNotification) {
-	chatIDRaw := strings.TrimSpace(os.Getenv(telegramChatEnv))
-	if chatIDRaw == "" {
+// telegramNotifier рассылает уведомления во все чаты с notify = true.
+// Если роль игрока в матче ещё неизвестна, она дописывается в отправленные
+// уведомления позже, см. roleUpdater.
+func telegramNotifier(cfg config, heroes map[int]string) func(matchNotification) {
+	chatIDs := cfg.NotifyChats()
+	if cfg.Telegram.Token == "" || len(chatIDs) == 0 {
 		return nil
 	}
-	chatID, err := strconv.ParseInt(chatIDRaw, 10, 64)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "invalid %s: %s\n", telegramChatEnv, err.Error())
-		return nil
-	}
-	apiBase := fmt.Sprintf(telegramBaseURL, token)
+	apiBase := fmt.Sprintf(telegramBaseURL, cfg.Telegram.Token)
+	roles := newRoleUpdater(apiBase, heroes)
 	return func(msg matchNotification) {
-		replyMarkup := buildMatchDetailsMarkup(msg)
-		if err := sendTelegramMessage(apiBase, chatID, msg.Text, "", replyMarkup); err != nil {
-			fmt.Fprintf(os.Stderr, "telegram notify error: %s\n", err.Error())
+		var sent []telegramMessage
+		for _, chatID := range chatIDs {
+			message, err := sendMatchNotification(apiBase, chatID, localeFor(chatID), msg)
+			if err != nil {
+				slog.Error("telegram notify failed", "chat_id", chatID, "account_id", msg.AccountID, "match_id", msg.MatchID, "error", err)
+				continue
+			}
+			sent = append(sent, message)
+		}
+		if len(sent) > 0 && matchRole(msg.Match) == roleUnknown {
+			roles.Add(msg, sent)
 		}
 	}
 }
 
-func buildMatchDetailsMarkup(msg matchNotification) any {
+func buildMatchDetailsMarkup(loc locale, msg matchNotification) any {
 	if msg.MatchID == 0 || msg.AccountID == 0 {
 		return nil
 	}
 	return map[string]any{
 		"inline_keyboard": [][]map[string]string{{
 			{
-				"text":          "Подробнее",
-				"callback_data": fmt.Sprintf("match:%d:%d", msg.AccountID, msg.MatchID),
+				"text":          loc.T("button.details"),
+				"callback_data": fmt.Sprintf("%s:%d:%d", callbackExpand, msg.AccountID, msg.MatchID),
 			},
 		}},
 	}
 }
 
-func handleTelegramCallback(apiBase string, query *telegramCallbackQuery, heroes map[int]string) error {
+type matchCallback struct {
+	Action    string
+	AccountID int64
+	MatchID   int64
+}
+
+func (b *telegramBot) handleCallback(query *telegramCallbackQuery) error {
 	if query == nil {
 		return nil
 	}
-	if err := answerTelegramCallback(apiBase, query.ID, "Загружаю детали матча"); err != nil {
+	if strings.HasPrefix(query.Data, callbackRating+":") {
+		return b.handleRatingCallback(query)
+	}
+	callback, ok := parseMatchCallbackData(query.Data)
+	if !ok || query.Message == nil {
+		return answerTelegramCallback(b.apiBase, query.ID, "")
+	}
+	chatID := query.Message.Chat.ID
+	loc := localeFor(chatID)
+	notice := loc.T("notice.match")
+	if callback.Action == callbackCollapse {
+		notice = ""
+	}
+	if err := answerTelegramCallback(b.apiBase, query.ID, notice); err != nil {
 		return err
 	}
-	accountID, matchID, ok := parseMatchCallbackData(query.Data)
-	if !ok {
+	details, err := fetchCachedMatchDetails(callback.MatchID)
+	if err != nil {
+		b.sendError(chatID, err)
 		return nil
 	}
-	if query.Message == nil {
-		return nil
+	if callback.Action == callbackCollapse {
+		msg, err := buildMatchNotificationFromDetails(details, callback.AccountID, b.heroes)
+		if err != nil {
+			b.sendError(chatID, err)
+			return nil
+		}
+		setMatchExpanded(chatID, query.Message.MessageID, false)
+		return b.editMatchMessage(query.Message, msg.Text, "", buildMatchDetailsMarkup(loc, msg))
 	}
-	details, err := fetchMatchDetails(matchID)
+	itemNames, err := fetchCachedItemNames()
 	if err != nil {
-		return sendTelegramMessage(apiBase, query.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
+		b.sendError(chatID, err)
+		return nil
 	}
-	itemNames, err := fetchItemNames()
+	text, err := formatMatchDetailsMessage(loc, details, callback.AccountID, b.heroes, itemNames)
 	if err != nil {
-		return sendTelegramMessage(apiBase, query.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
+		b.sendError(chatID, err)
+		return nil
 	}
-	text, err := formatMatchDetailsMessage(details, accountID, heroes, itemNames)
-	if err != nil {
-		return sendTelegramMessage(apiBase, query.Message.Chat.ID, fmt.Sprintf("Ошибка: %s", err.Error()), "", nil)
+	markup := buildExpandedMatchMarkup(loc, details, callback.AccountID, b.accountStore.Get(), b.heroes)
+	if len(query.Message.Photo) == 0 || runeLen(text) <= telegramCaptionMax {
+		// Подробности заменяют само уведомление, а не приходят отдельно.
+		setMatchExpanded(chatID, query.Message.MessageID, true)
 	}
-	return sendTelegramMessage(apiBase, query.Message.Chat.ID, text, "HTML", nil)
+	return b.editMatchMessage(query.Message, text, "HTML", markup)
 }
 
-func parseMatchCallbackData(data string) (int64, int64, bool) {
+// editMatchMessage меняет текст уведомления; у карточек матча вместо текста
+// редактируется подпись к фото.
+func (b *telegramBot) editMatchMessage(msg *telegramMessage, text string, parseMode string, replyMarkup any) error {
+	if len(msg.Photo) == 0 {
+		return editTelegramMessage(b.apiBase, msg.Chat.ID, msg.MessageID, text, parseMode, replyMarkup)
+	}
+	if runeLen(text) > telegramCaptionMax {
+		return sendTelegramMessage(b.apiBase, msg.Chat.ID, text, parseMode, replyMarkup)
+	}
+	return editTelegramCaption(b.apiBase, msg.Chat.ID, msg.MessageID, text, parseMode, replyMarkup)
+}
+
+// buildExpandedMatchMarkup добавляет кнопку "Свернуть" и переключатели
+// на других отслеживаемых игроков из того же матча.
+func buildExpandedMatchMarkup(loc locale, details matchDetails, accountID int64, tracked []int64, heroes map[int]string) any {
+	rows := [][]map[string]string{{
+		{
+			"text":          loc.T("button.collapse"),
+			"callback_data": fmt.Sprintf("%s:%d:%d", callbackCollapse, accountID, details.MatchID),
+		},
+	}}
+	trackedSet := make(map[int64]struct{}, len(tracked))
+	for _, id := range tracked {
+		trackedSet[id] = struct{}{}
+	}
+	var switches []map[string]string
+	for _, player := range details.Players {
+		if player.AccountID == accountID {
+			continue
+		}
+		if _, ok := trackedSet[player.AccountID]; !ok {
+			continue
+		}
+		heroName := heroes[player.HeroID]
+		if heroName == "" {
+			heroName = fmt.Sprintf("Hero #%d", player.HeroID)
+		}
+		switches = append(switches, map[string]string{
+			"text":          fmt.Sprintf("%s (%s)", displayName(player.AccountID, player.PersonaName), heroName),
+			"callback_data": fmt.Sprintf("%s:%d:%d", callbackExpand, player.AccountID, details.MatchID),
+		})
+	}
+	for len(switches) > 0 {
+		n := min(2, len(switches))
+		rows = append(rows, switches[:n])
+		switches = switches[n:]
+	}
+	return map[string]any{"inline_keyboard": rows}
+}
+
+func parseMatchCallbackData(data string) (matchCallback, bool) {
 	parts := strings.Split(data, ":")
-	if len(parts) != 3 || parts[0] != "match" {
-		return 0, 0, false
+	if len(parts) != 3 || (parts[0] != callbackExpand && parts[0] != callbackCollapse) {
+		return matchCallback{}, false
 	}
 	accountID, err := strconv.ParseInt(parts[1], 10, 64)
 	if err != nil {
-		return 0, 0, false
+		return matchCallback{}, false
 	}
 	matchID, err := strconv.ParseInt(parts[2], 10, 64)
 	if err != nil {
-		return 0, 0, false
+		return matchCallback{}, false
+	}
+	return matchCallback{Action: parts[0], AccountID: accountID, MatchID: matchID}, true
+}
+
+func editTelegramMessage(apiBase string, chatID int64, messageID int, text string, parseMode string, replyMarkup any) error {
+	payload := map[string]any{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       text,
+	}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
+	if replyMarkup != nil {
+		payload["reply_markup"] = replyMarkup
+	}
+	return callTelegram(apiBase, "editMessageText", payload, nil)
+}
+
+func editTelegramCaption(apiBase string, chatID int64, messageID int, caption string, parseMode string, replyMarkup any) error {
+	payload := map[string]any{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"caption":    caption,
+	}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
+	if replyMarkup != nil {
+		payload["reply_markup"] = replyMarkup
 	}
-	return accountID, matchID, true
+	return callTelegram(apiBase, "editMessageCaption", payload, nil)
 }
 
 func answerTelegramCallback(apiBase string, callbackID string, text string) error {
@@ -406,7 +721,7 @@ func answerTelegramCallback(apiBase string, callbackID string, text string) erro
 	client := &http.Client{Timeout: requestTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("telegram callback answer: %w", err)
+		return fmt.Errorf("telegram callback answer: %w", withoutURL(err))
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
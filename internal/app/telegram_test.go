@@ -52,14 +52,40 @@ func TestBuildTelegramMessages_Long(t *testing.T) {
 }
 
 func TestParseMatchCallbackData(t *testing.T) {
-	accountID, matchID, ok := parseMatchCallbackData("match:123:456")
+	callback, ok := parseMatchCallbackData("match:123:456")
 	if !ok {
 		t.Fatal("expected callback data to parse")
 	}
-	if accountID != 123 || matchID != 456 {
-		t.Fatalf("unexpected parsed values: accountID=%d matchID=%d", accountID, matchID)
+	if callback.Action != callbackExpand || callback.AccountID != 123 || callback.MatchID != 456 {
+		t.Fatalf("unexpected parsed values: %+v", callback)
 	}
-	if _, _, ok := parseMatchCallbackData("bad:data"); ok {
+	if callback, ok := parseMatchCallbackData("collapse:1:2"); !ok || callback.Action != callbackCollapse {
+		t.Fatalf("expected collapse callback to parse, got %+v", callback)
+	}
+	if _, ok := parseMatchCallbackData("bad:data"); ok {
 		t.Fatal("expected invalid callback data to fail")
 	}
 }
+
+func TestBuildExpandedMatchMarkup(t *testing.T) {
+	details := matchDetails{
+		MatchID: 9,
+		Players: []matchDetailsPlayer{
+			{AccountID: 1, PersonaName: "A", HeroID: 1},
+			{AccountID: 2, PersonaName: "B", HeroID: 2},
+			{AccountID: 3, PersonaName: "Stranger", HeroID: 3},
+		},
+	}
+	markup := buildExpandedMatchMarkup(newLocale(langRU), details, 1, []int64{1, 2}, map[int]string{2: "Bane"}).(map[string]any)
+	rows := markup["inline_keyboard"].([][]map[string]string)
+	// Первая строка — "Свернуть", вторая — переключатель на второго отслеживаемого игрока.
+	if len(rows) != 2 {
+		t.Fatalf("rows=%d, want 2", len(rows))
+	}
+	if rows[0][0]["callback_data"] != "collapse:1:9" {
+		t.Fatalf("unexpected collapse button: %v", rows[0][0])
+	}
+	if len(rows[1]) != 1 || rows[1][0]["callback_data"] != "match:2:9" || rows[1][0]["text"] != "B (Bane)" {
+		t.Fatalf("unexpected switch buttons: %v", rows[1])
+	}
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML разбирает подмножество TOML, которого хватает для конфига:
+// таблицы [name], массивы таблиц [[name]], ключи key = value со строками,
+// целыми, bool и массивами из них (массив может занимать несколько строк).
+// Вложенные таблицы, точечные ключи, даты и дробные числа не поддерживаются.
+// Таблицы возвращаются как map[string]any, массивы таблиц — []map[string]any.
+func parseTOML(source string, data string) (map[string]any, error) {
+	root := make(map[string]any)
+	current := root
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		fail := func(format string, args ...any) error {
+			return fmt.Errorf("%s:%d: %s", source, lineNo, fmt.Sprintf(format, args...))
+		}
+		line := strings.TrimSpace(stripTOMLComment(lines[i]))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[[") {
+			if !strings.HasSuffix(line, "]]") {
+				return nil, fail("ожидалось ]]")
+			}
+			name := strings.TrimSpace(line[2 : len(line)-2])
+			if !isTOMLKey(name) {
+				return nil, fail("некорректное имя массива таблиц %q", name)
+			}
+			var list []map[string]any
+			if existing, ok := root[name]; ok {
+				list, ok = existing.([]map[string]any)
+				if !ok {
+					return nil, fail("%s уже объявлен как таблица", name)
+				}
+			}
+			current = make(map[string]any)
+			root[name] = append(list, current)
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fail("ожидалось ]")
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if !isTOMLKey(name) {
+				return nil, fail("некорректное имя таблицы %q", name)
+			}
+			if _, ok := root[name]; ok {
+				return nil, fail("таблица %s объявлена повторно", name)
+			}
+			current = make(map[string]any)
+			root[name] = current
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fail("ожидалось key = value")
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if !isTOMLKey(key) {
+			return nil, fail("некорректный ключ %q", key)
+		}
+		if _, exists := current[key]; exists {
+			return nil, fail("ключ %s задан повторно", key)
+		}
+		for strings.HasPrefix(value, "[") && !tomlBracketsClosed(value) && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(stripTOMLComment(lines[i]))
+		}
+		parsed, err := parseTOMLValue(value)
+		if err != nil {
+			return nil, fail("%s: %s", key, err.Error())
+		}
+		current[key] = parsed
+	}
+	return root, nil
+}
+
+func isTOMLKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// stripTOMLComment отрезает комментарий, не трогая # внутри строк.
+func stripTOMLComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func tomlBracketsClosed(value string) bool {
+	depth := 0
+	var quote rune
+	for _, r := range value {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+func parseTOMLValue(value string) (any, error) {
+	switch {
+	case value == "":
+		return nil, fmt.Errorf("нет значения")
+	case value == "true":
+		return true, nil
+	case value == "false":
+		return false, nil
+	case strings.HasPrefix(value, `"`):
+		if len(value) < 2 || !strings.HasSuffix(value, `"`) {
+			return nil, fmt.Errorf("незакрытая строка")
+		}
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная строка %s", value)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return nil, fmt.Errorf("незакрытая строка")
+		}
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, "["):
+		return parseTOMLArray(value)
+	}
+	number, err := strconv.ParseInt(strings.ReplaceAll(value, "_", ""), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("неподдерживаемое значение %s (строки берите в кавычки)", value)
+	}
+	return number, nil
+}
+
+func parseTOMLArray(value string) ([]any, error) {
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("незакрытый массив")
+	}
+	inner := strings.TrimSpace(value[1 : len(value)-1])
+	var items []any
+	var quote rune
+	start := 0
+	flush := func(end int) error {
+		item := strings.TrimSpace(inner[start:end])
+		if item == "" {
+			return nil
+		}
+		if strings.HasPrefix(item, "[") {
+			return fmt.Errorf("вложенные массивы не поддерживаются")
+		}
+		parsed, err := parseTOMLValue(item)
+		if err != nil {
+			return err
+		}
+		items = append(items, parsed)
+		return nil
+	}
+	for i, r := range inner {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			if err := flush(i); err != nil {
+				return nil, err
+			}
+			start = i + 1
+		}
+	}
+	if err := flush(len(inner)); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
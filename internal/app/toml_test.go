@@ -0,0 +1,62 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	doc, err := parseTOML("config.toml", `
+# комментарий
+[telegram]
+token = "123:abc # не комментарий"
+
+[monitor]
+poll_interval = '10m' # комментарий
+
+[[accounts]]
+id = 1_000
+notify = false
+
+[[accounts]]
+id = 2
+tags = [
+  "a", "b",
+]
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	telegram := doc["telegram"].(map[string]any)
+	if telegram["token"] != "123:abc # не комментарий" {
+		t.Fatalf("token=%q", telegram["token"])
+	}
+	if doc["monitor"].(map[string]any)["poll_interval"] != "10m" {
+		t.Fatalf("monitor=%v", doc["monitor"])
+	}
+	accounts := doc["accounts"].([]map[string]any)
+	if len(accounts) != 2 || accounts[0]["id"] != int64(1000) || accounts[0]["notify"] != false {
+		t.Fatalf("accounts=%v", accounts)
+	}
+	tags := accounts[1]["tags"].([]any)
+	if len(tags) != 2 || tags[1] != "b" {
+		t.Fatalf("tags=%v", tags)
+	}
+}
+
+func TestParseTOMLErrorsHaveLineNumbers(t *testing.T) {
+	cases := map[string]string{
+		"[telegram]\ntoken = abc":          "config.toml:2:",
+		"[a]\n[a]":                         "config.toml:2:",
+		"key = 1\nkey = 2":                 "config.toml:2:",
+		"[[accounts]\nid = 1":              "config.toml:1:",
+		"\n\nname = \"unterminated":        "config.toml:3:",
+		"[accounts]\n[[accounts]]\nid = 1": "config.toml:2:",
+	}
+	for input, want := range cases {
+		_, err := parseTOML("config.toml", input)
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Fatalf("input %q: err=%v, want prefix %q", input, err, want)
+		}
+	}
+}